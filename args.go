@@ -0,0 +1,208 @@
+package eflag
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	PASSTHROUGH_TAG_KEY = "passthrough"
+	ARG_TAG_KEY         = "arg"
+
+	ARG_REST = "rest" // arg:"rest" binds the remaining positional args
+)
+
+// argField is a field bound to positional args by the arg tag, eg: arg:"0"
+type argField struct {
+	*flagField
+	index int
+	rest  bool
+}
+
+// parseArgs parse the options of args, collecting positional args.
+// In interspersed mode options may follow positional args, eg: app file1 -v
+// Args after -- are passed through untouched to the passthrough field,
+// or are positional args when there is none.
+func (e *EFlag) parseArgs(args []string) error {
+	for {
+		if err := e.flagSet.Parse(args); err != nil {
+			return err
+		}
+		rest := e.flagSet.Args()
+		if e.endsWithTerminator(args[:len(args)-len(rest)]) {
+			e.setPassthrough(rest)
+			return nil
+		}
+		if len(rest) == 0 {
+			return nil
+		}
+		if !e.config.Interspersed {
+			e.splitPassthrough(rest)
+			return nil
+		}
+		e.args = append(e.args, rest[0])
+		args = rest[1:]
+	}
+}
+
+// endsWithTerminator report whether the options parsed by the flag package
+// end with the -- terminator, not with -- as the value of a flag, eg: -name --
+func (e *EFlag) endsWithTerminator(parsed []string) bool {
+	for i := 0; i < len(parsed); i++ {
+		if parsed[i] == "--" {
+			return i == len(parsed)-1
+		}
+		name := strings.TrimLeft(parsed[i], "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		if fl := e.flagSet.Lookup(name); fl != nil && !isBoolValue(fl.Value) {
+			i++ // the value of the flag
+		}
+	}
+	return false
+}
+
+// splitPassthrough collect positional args before --, passing through the args after it.
+func (e *EFlag) splitPassthrough(args []string) {
+	if e.passthrough.IsValid() {
+		for i, arg := range args {
+			if arg == "--" {
+				e.args = append(e.args, args[:i]...)
+				e.setPassthrough(args[i+1:])
+				return
+			}
+		}
+	}
+	e.args = append(e.args, args...)
+}
+
+// setPassthrough set args after -- to the passthrough field, or to positional args.
+func (e *EFlag) setPassthrough(args []string) {
+	if !e.passthrough.IsValid() {
+		e.args = append(e.args, args...)
+		return
+	}
+	e.passthrough.Set(reflect.ValueOf(append([]string{}, args...)))
+}
+
+// parsePassthrough record the string slice field tagged passthrough:"true".
+func (e *EFlag) parsePassthrough(field reflect.StructField, fieldValue reflect.Value) bool {
+	if !ParseBool(field.Tag.Get(PASSTHROUGH_TAG_KEY), false) || !isStringSlice(field) {
+		return false
+	}
+	e.passthrough = fieldValue
+	return true
+}
+
+// Args returns the positional args after Parse.
+func (e *EFlag) Args() []string {
+	return e.args
+}
+
+// parsePositional record the field tagged arg, binding it to the positional
+// arg of the index, or to the remaining args for arg:"rest".
+func (e *EFlag) parsePositional(scope structScope, field reflect.StructField, fieldValue reflect.Value) (bool, error) {
+	tag, ok := field.Tag.Lookup(ARG_TAG_KEY)
+	if !ok {
+		return false, nil
+	}
+
+	f := newFlagField(strings.ToUpper(field.Name), field, fieldValue, e.config)
+	f.fieldName = scope.path + field.Name
+	f.owner = scope.owner
+	f.usage = field.Tag.Get("usage")
+	f.val.name = "" // errors are reported with the arg name
+	af := &argField{flagField: f, rest: tag == ARG_REST}
+	if af.rest {
+		if field.Type.Kind() != reflect.Slice {
+			return true, fmt.Errorf("arg tag %q of %s requires a slice type", tag, f)
+		}
+	} else {
+		index, err := strconv.Atoi(tag)
+		if err != nil || index < 0 {
+			return true, fmt.Errorf("invalid arg tag %q of %s", tag, f)
+		}
+		af.index = index
+	}
+
+	if err := e.parseDefault(scope.recv, field, f); err != nil {
+		return true, err
+	}
+	if err := parseRequired(field, f); err != nil {
+		return true, err
+	}
+	complete, err := completeMethod(scope.recv, field.Name+COMPLETE_METHOD_NAME_KEY)
+	if err != nil {
+		return true, err
+	}
+	f.complete = complete
+	f.description = callDescribeMethod(scope.recv, field.Name+DESCRIPTION_METHOD_NAME_KEY)
+	e.positionals = append(e.positionals, af)
+	return true, nil
+}
+
+// checkPositionals sort positional fields by index, checking the indexes
+// are 0..n-1 and arg:"rest" is declared at most once.
+func (e *EFlag) checkPositionals() error {
+	sort.SliceStable(e.positionals, func(i, j int) bool {
+		a, b := e.positionals[i], e.positionals[j]
+		if a.rest != b.rest {
+			return b.rest
+		}
+		return a.index < b.index
+	})
+	for i, af := range e.positionals {
+		if af.rest {
+			if i != len(e.positionals)-1 {
+				return fmt.Errorf("arg tag %q of %s redefined", ARG_REST, af)
+			}
+			af.index = i
+		} else if af.index != i {
+			return fmt.Errorf("arg tag %q of %s must follow arg %d", strconv.Itoa(af.index), af, i)
+		}
+	}
+	return nil
+}
+
+// setPositionals convert the positional args to their fields.
+func (e *EFlag) setPositionals() error {
+	for _, af := range e.positionals {
+		src := Source{Kind: SOURCE_COMMAND_LINE, Name: af.name}
+		if af.rest {
+			if af.index >= len(e.args) {
+				break
+			}
+			p := &valueParser{strict: e.config.StrictValues}
+			val, err := p.parseSlice(af.typ, e.args[af.index:])
+			if err != nil {
+				return fmt.Errorf("arg %s: %w", af.name, err)
+			}
+			af.setValue(val, src)
+			break
+		}
+		if af.index >= len(e.args) {
+			break
+		}
+		if err := af.set(e.args[af.index], src); err != nil {
+			return fmt.Errorf("arg %s: %w", af.name, err)
+		}
+	}
+	return nil
+}
+
+// positionalUsage returns the positional args of the usage line, eg: SRC DST FILES...
+func (e *EFlag) positionalUsage() string {
+	names := make([]string, 0, len(e.positionals))
+	for _, af := range e.positionals {
+		if af.rest {
+			names = append(names, af.name+"...")
+		} else {
+			names = append(names, af.name)
+		}
+	}
+	return strings.Join(names, " ")
+}
@@ -0,0 +1,158 @@
+package eflag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type argsOptions struct {
+	Verbose bool   `flag:"verbose" flag_short:"v"`
+	Output  string `flag:"output" flag_short:"o"`
+
+	Args  []string
+	Child []string `passthrough:"true"`
+}
+
+func TestInterspersed(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &argsOptions{}
+	ef, err := parseWith(t, COMMAND_MODE_OPTION, opt, []string{"file1", "-v", "file2", "-o", "out", "file3"}, WithInterspersed())
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("out", opt.Output)
+	assert.Equal([]string{"file1", "file2", "file3"}, opt.Args)
+	assert.Equal([]string{"file1", "file2", "file3"}, ef.Args())
+	assert.Nil(opt.Child)
+
+	opt = &argsOptions{}
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"file1", "-v", "--", "-o", "x", "--"}, WithInterspersed())
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("", opt.Output)
+	assert.Equal([]string{"file1"}, opt.Args)
+	assert.Equal([]string{"-o", "x", "--"}, opt.Child)
+
+	opt = &argsOptions{}
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"file1", "-vo", "out", "file2", "--", "-v"}, WithInterspersed(), WithGNUStyle())
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("out", opt.Output)
+	assert.Equal([]string{"file1", "file2"}, opt.Args)
+	assert.Equal([]string{"-v"}, opt.Child)
+
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &argsOptions{}, []string{"file1", "-x"}, WithInterspersed())
+	assert.EqualError(err, "flag provided but not defined: -x")
+}
+
+func TestPassthrough(t *testing.T) {
+	assert := assert.New(t)
+
+	// options stop at the first positional arg
+	opt := &argsOptions{}
+	_, err := parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-v", "file1", "-o", "x", "--", "-v"})
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("", opt.Output)
+	assert.Equal([]string{"file1", "-o", "x"}, opt.Args)
+	assert.Equal([]string{"-v"}, opt.Child)
+
+	opt = &argsOptions{}
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-v", "--", "file1"})
+	assert.Nil(err)
+	assert.Nil(opt.Args)
+	assert.Equal([]string{"file1"}, opt.Child)
+
+	opt = &argsOptions{}
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-v", "--"})
+	assert.Nil(err)
+	assert.Equal([]string{}, opt.Child)
+
+	// -- as the value of a flag is not the terminator
+	for _, options := range [][]EFlagOption{nil, {WithInterspersed()}} {
+		opt = &argsOptions{}
+		_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-o", "--", "f"}, options...)
+		assert.Nil(err)
+		assert.Equal("--", opt.Output)
+		assert.Equal([]string{"f"}, opt.Args)
+		assert.Nil(opt.Child)
+
+		opt = &argsOptions{}
+		_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-o", "--", "--", "f"}, options...)
+		assert.Nil(err)
+		assert.Equal("--", opt.Output)
+		assert.Nil(opt.Args)
+		assert.Equal([]string{"f"}, opt.Child)
+	}
+
+	// without passthrough field, args after -- are positional args
+	setArgs(t, "-v", "--", "-v")
+	gopt := &gnuOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(gopt))
+	assert.Equal([]string{"-v"}, gopt.Args)
+}
+
+type copyOptions struct {
+	Verbose bool     `flag:"v"`
+	Src     string   `arg:"0" usage:"source" required:"true"`
+	Dst     string   `arg:"1" default:"."`
+	Files   []int    `arg:"rest"`
+	Count   *float64 `arg:"2"`
+
+	Args []string
+}
+
+func TestPositional(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &copyOptions{}
+	ef, err := parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-v", "a.txt", "b", "1.5", "3", "4"})
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("a.txt", opt.Src)
+	assert.Equal("b", opt.Dst)
+	assert.Equal(1.5, *opt.Count)
+	assert.Equal([]int{3, 4}, opt.Files)
+	assert.Equal([]string{"a.txt", "b", "1.5", "3", "4"}, opt.Args)
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "SRC"}, ef.Source("Src"))
+
+	opt = &copyOptions{}
+	ef, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"a.txt"})
+	assert.Nil(err)
+	assert.Equal(".", opt.Dst)
+	assert.Nil(opt.Count)
+	assert.Nil(opt.Files)
+	assert.Equal(Source{Kind: SOURCE_DEFAULT_TAG}, ef.Source("DST"))
+
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &copyOptions{}, []string{"-v"})
+	var rerr *RequiredError
+	assert.ErrorAs(err, &rerr)
+	assert.Equal([]string{"SRC"}, rerr.Args)
+	assert.EqualError(err, "missing required args: SRC")
+
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &copyOptions{}, []string{"a", "b", "c"})
+	var verr *ValueError
+	assert.ErrorAs(err, &verr)
+	assert.EqualError(err, `arg COUNT: invalid value "c": expected float64: invalid syntax`)
+
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &copyOptions{}, []string{"a", "b", "1", "x"})
+	assert.EqualError(err, `arg FILES: invalid value "x": expected int: invalid syntax`)
+}
+
+func TestPositionalUsage(t *testing.T) {
+	ef, _ := parseWith(t, COMMAND_MODE_OPTION, &copyOptions{}, []string{"a"})
+	assert.Equal(t, "SRC DST COUNT FILES...", ef.positionalUsage())
+
+	setArgs(t)
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&struct {
+		A string `arg:"0"`
+		B string `arg:"2"`
+	}{})
+	assert.EqualError(t, err, `arg tag "2" of B must follow arg 1`)
+
+	err = NewEFlag(COMMAND_MODE_OPTION).Parse(&struct {
+		A string `arg:"rest"`
+	}{})
+	assert.EqualError(t, err, `arg tag "rest" of A requires a slice type`)
+}
@@ -1,6 +1,10 @@
 package eflag
 
 import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
 	"reflect"
 	"strings"
 
@@ -13,6 +17,10 @@ const (
 	COMMAND_FIELD_TAG_KEY       = "command"
 	COMMAND_METHOD_NAME_KEY     = "Command"
 	COMMAND_SUB_COMMAND_TAG_KEY = "sub_command"
+	COMMAND_OPTIONS_TAG_KEY     = "commands" // sub-commands accepting the option, eg: commands:"show,detail"
+	COMMAND_GLOBAL_TAG_KEY      = "global"   // option accepted before the sub-command name, eg: global:"true"
+	COMMAND_ALIASES_TAG_KEY     = "aliases"  // other names of sub-command, eg: aliases:"ls,list"
+	COMMAND_HELP_NAME           = "help"
 
 	SUM_COMMAND_INDEX = 1
 
@@ -22,18 +30,28 @@ const (
 
 type Command struct {
 	Name       string
+	Aliases    []string
 	MethodName string
 	Mode       CommandMode
 	Usage      string
 	runFlag    string
-	rv         reflect.Value
-	value      reflect.Value
+	rv         reflect.Value // receiver of the <Name>Command method
+	value      reflect.Value // option field, or struct pointer of sub-command
+	sub        *EFlag        // flags and sub-commands of struct sub-command
 }
 
 func (c *Command) UsageString() string {
+	if len(c.Aliases) > 0 {
+		return c.Name + " (" + strings.Join(c.Aliases, ",") + ")    " + c.Usage
+	}
 	return c.Name + "    " + c.Usage
 }
 
+// names returns the name and aliases of c.
+func (c *Command) names() []string {
+	return append([]string{c.Name}, c.Aliases...)
+}
+
 func formatCommandUsage(cmds []*Command) string {
 	usages := make([]string, 0, len(cmds))
 	for _, cmd := range cmds {
@@ -60,11 +78,104 @@ func (c *Command) ShouldRun(name string) bool {
 	return false
 }
 
-func (c *Command) Run() {
-	method := c.rv.MethodByName(c.MethodName + COMMAND_METHOD_NAME_KEY)
-	if method.IsValid() {
-		method.Call(nil)
+var (
+	contextType     = reflect.TypeOf((*context.Context)(nil)).Elem()
+	stringSliceType = reflect.TypeOf([]string(nil))
+)
+
+// Run call the <Name>Command method, returning its error.
+// Supported methods: func(), func() error, func(ctx context.Context) error,
+// func(ctx context.Context, args []string) error
+func (c *Command) Run(ctx context.Context, args []string) error {
+	method, err := c.method()
+	if err != nil || !method.IsValid() {
+		return err
+	}
+
+	in := []reflect.Value{reflect.ValueOf(&ctx).Elem(), reflect.ValueOf(args)}
+	out := method.Call(in[:method.Type().NumIn()])
+	if len(out) == 0 || out[0].IsNil() {
+		return nil
+	}
+	return out[0].Interface().(error)
+}
+
+// method returns the <Name>Command method, checking its signature.
+func (c *Command) method() (reflect.Value, error) {
+	name := c.MethodName + COMMAND_METHOD_NAME_KEY
+	method := c.rv.MethodByName(name)
+	if !method.IsValid() {
+		return method, nil
+	}
+
+	typ := method.Type()
+	ok := typ.NumOut() == 0 && typ.NumIn() == 0
+	if typ.NumOut() == 1 && typ.Out(0) == errorType {
+		switch typ.NumIn() {
+		case 0:
+			ok = true
+		case 1:
+			ok = typ.In(0) == contextType
+		case 2:
+			ok = typ.In(0) == contextType && typ.In(1) == stringSliceType
+		}
+	}
+	if !ok {
+		return reflect.Value{}, fmt.Errorf("method %s of %s must be func() error, func(context.Context) error or func(context.Context, []string) error", name, c.rv.Type())
+	}
+	return method, nil
+}
+
+// checkCommandMethods returns an error if a command method has an unsupported signature.
+func (e *EFlag) checkCommandMethods() error {
+	for _, cmd := range e.commandList {
+		if _, err := cmd.method(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shouldRunSub report whether a sub-command of c runs instead of c, which is
+// when one is given, or c has sub-commands but no command method.
+func (c *Command) shouldRunSub() bool {
+	if c.sub.commandName != "" {
+		return true
+	}
+	method, _ := c.method()
+	return !method.IsValid() && len(c.sub.commandList) > 0
+}
+
+// parseSubCommand bind struct-typed sub_command field to its own EFlag, which
+// parses the args after the sub-command name, eg: app remote add -name x
+// The <Name>Command method is resolved on the sub-command struct, then on rv.
+func (e *EFlag) parseSubCommand(cmd *Command, field reflect.StructField, fieldValue reflect.Value) error {
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	if fieldValue.Kind() == reflect.Struct {
+		fieldValue = fieldValue.Addr()
+	} else if fieldValue.IsNil() {
+		fieldValue.Set(reflect.New(typ))
+	}
+	if fieldValue.MethodByName(cmd.MethodName + COMMAND_METHOD_NAME_KEY).IsValid() {
+		cmd.rv = fieldValue
+	}
+	cmd.value = fieldValue
+
+	// the config file only applies to top-level options
+	config := *e.config
+	config.ConfigFile, config.ConfigFlag = "", ""
+	cmd.sub = newEFlag(e.flagSet.Name()+" "+cmd.Name, COMMAND_MODE_SUB_CMD, &config)
+	if err := cmd.sub.register(fieldValue); err != nil {
+		return fmt.Errorf("sub command %s: %w", cmd.Name, err)
 	}
+	return nil
 }
 
 // Format: methodName,runFlag
@@ -96,3 +207,243 @@ func defaultRunFlag(kind reflect.Kind) string {
 	}
 	return ""
 }
+
+// parseOptionCommands parse the commands tag of field.
+func parseOptionCommands(field reflect.StructField) []string {
+	return splitNames(field.Tag.Get(COMMAND_OPTIONS_TAG_KEY))
+}
+
+// parseCommandAliases parse the aliases tag of field.
+func parseCommandAliases(field reflect.StructField) []string {
+	return splitNames(field.Tag.Get(COMMAND_ALIASES_TAG_KEY))
+}
+
+// splitNames split comma separated names, eg: show,detail
+func splitNames(s string) []string {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// lookupCommand find sub-command by name or alias.
+func (e *EFlag) lookupCommand(name string) *Command {
+	if !e.isMode(COMMAND_MODE_SUB_CMD) {
+		return nil
+	}
+	for _, cmd := range e.commandList {
+		for _, n := range cmd.names() {
+			if n == name {
+				return cmd
+			}
+		}
+	}
+	return nil
+}
+
+// resolveCommand returns the sub-command name of name, which may be an alias,
+// or a unique prefix when prefix matching is enabled.
+func (e *EFlag) resolveCommand(name string) string {
+	if cmd := e.lookupCommand(name); cmd != nil {
+		return cmd.Name
+	}
+	if !e.config.PrefixMatching || name == "" {
+		return name
+	}
+
+	var found *Command
+	for _, cmd := range e.commandList {
+		for _, n := range cmd.names() {
+			if !strings.HasPrefix(n, name) {
+				continue
+			}
+			if found != nil && found != cmd {
+				return name // ambiguous
+			}
+			found = cmd
+		}
+	}
+	if found == nil {
+		return name
+	}
+	return found.Name
+}
+
+// checkOptionCommands returns an error if a commands tag refers to an unknown sub-command.
+func (e *EFlag) checkOptionCommands() error {
+	for _, f := range e.fields {
+		for _, name := range f.commands {
+			if e.lookupCommand(name) == nil {
+				return fmt.Errorf("commands tag of %s refers to unknown sub command %s", f, name)
+			}
+		}
+	}
+	return nil
+}
+
+// isAllowed report whether the current sub-command accepts option f.
+func (e *EFlag) isAllowed(f *flagField) bool {
+	return e.isAllowedIn(f, e.commandName)
+}
+
+// isAllowedIn report whether sub-command commandName accepts option f.
+func (e *EFlag) isAllowedIn(f *flagField, commandName string) bool {
+	if len(f.commands) == 0 || !e.isMode(COMMAND_MODE_SUB_CMD) {
+		return true
+	}
+	for _, name := range f.commands {
+		if name == commandName {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlagAllowed report whether the current sub-command accepts flag name.
+func (e *EFlag) isFlagAllowed(name string) bool {
+	f := e.lookupFlag(name)
+	return f == nil || e.isAllowed(f)
+}
+
+// checkCommandOptions returns an error if an option of another sub-command is on the command-line.
+func (e *EFlag) checkCommandOptions() (err error) {
+	e.flagSet.Visit(func(fl *flag.Flag) {
+		if err != nil || e.isFlagAllowed(fl.Name) {
+			return
+		}
+		name := e.flagDisplayName(fl.Name)
+		if e.commandName == "" {
+			err = e.failf("flag %s requires sub command %s", name, strings.Join(e.lookupFlag(fl.Name).commands, ","))
+		} else {
+			err = e.failf("flag %s is not an option of sub command %s", name, e.commandName)
+		}
+	})
+	return
+}
+
+// isHelpCommand report whether the sub-command is the built-in help, eg: app help show
+func (e *EFlag) isHelpCommand() bool {
+	return e.isMode(COMMAND_MODE_SUB_CMD) && e.commandName == COMMAND_HELP_NAME && e.lookupCommand(COMMAND_HELP_NAME) == nil
+}
+
+// help print the usage of the sub-command named by args, eg: app help remote add
+// Like the -h flag, it returns flag.ErrHelp unless exiting as configured.
+func (e *EFlag) help(args []string) error {
+	target := e
+	target.commandName = ""
+	for _, name := range args {
+		if strings.HasPrefix(name, "-") {
+			continue
+		}
+		cmd := target.lookupCommand(target.resolveCommand(name))
+		if cmd == nil {
+			return target.failf("unknown help topic %q%s", name, target.suggestCommand(name))
+		}
+		if cmd.sub == nil {
+			target.commandName = cmd.Name
+			break
+		}
+		target = cmd.sub
+	}
+	target.Usage()
+	return e.done(flag.ErrHelp)
+}
+
+// done end a built-in command like the -h flag does, exiting with 0 or
+// panicking as configured, otherwise returning err.
+func (e *EFlag) done(err error) error {
+	switch e.config.ErrorHandling {
+	case flag.ExitOnError:
+		os.Exit(0)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// inheritGlobals register the global options to the struct sub-commands, recursively.
+func (e *EFlag) inheritGlobals() error {
+	for _, f := range e.fields {
+		if !f.global {
+			continue
+		}
+		for _, cmd := range e.commandList {
+			if cmd.sub == nil {
+				continue
+			}
+			if err := cmd.sub.addGlobal(f); err != nil {
+				return fmt.Errorf("sub command %s: %w", cmd.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *EFlag) addGlobal(f *flagField) error {
+	if err := e.checkRedefined(f); err != nil {
+		return err
+	}
+	e.varFlag(f)
+	e.globals = append(e.globals, f)
+	for _, cmd := range e.commandList {
+		if cmd.sub == nil {
+			continue
+		}
+		if err := cmd.sub.addGlobal(f); err != nil {
+			return fmt.Errorf("sub command %s: %w", cmd.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitGlobalArgs split the options before the sub-command name from args,
+// returning the first of them that is not a global option, if any.
+func (e *EFlag) splitGlobalArgs(args []string) (globals, rest []string, local string, err error) {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return args[:i], args[i:], local, nil
+		}
+
+		names, needValue := e.optionNames(arg)
+		for _, name := range names {
+			if e.flagSet.Lookup(name) == nil {
+				if name == "h" || name == "help" {
+					continue // print usage when parsing
+				}
+				return nil, nil, "", e.failf("flag provided but not defined: %s", e.flagDisplayName(name))
+			}
+			if f := e.lookupFlag(name); f != nil && !f.global && local == "" {
+				local = name
+			}
+		}
+		if needValue {
+			i++
+		}
+	}
+	return args, nil, local, nil
+}
+
+// optionNames returns the flag names of option arg, and whether the next arg is its value.
+// In GNU style, -vxf is the short options v, x and f.
+func (e *EFlag) optionNames(arg string) (names []string, needValue bool) {
+	option := strings.TrimPrefix(strings.TrimPrefix(arg, "-"), "-")
+	name, _, hasValue := splitOption(option)
+	if e.config.GNUStyle && !strings.HasPrefix(arg, "--") && len(name) > 1 && !e.isShort(name) {
+		for j := 0; j < len(option); j++ {
+			name := option[j : j+1]
+			names = append(names, name)
+			if fl := e.flagSet.Lookup(name); fl == nil || !isBoolValue(fl.Value) {
+				// the rest of option is the value
+				return names, fl != nil && j == len(option)-1
+			}
+		}
+		return names, false
+	}
+
+	fl := e.flagSet.Lookup(name)
+	return []string{name}, fl != nil && !hasValue && !isBoolValue(fl.Value)
+}
@@ -0,0 +1,451 @@
+package eflag
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gitOptions struct {
+	Verbose bool   `flag:"v"`
+	Dir     string `flag:"C" global:"true"`
+	Debug   bool   `flag:"debug" global:"true"`
+
+	Remote *remoteCommand `sub_command:"remote" usage:"manage remotes"`
+	Status statusCommand  `sub_command:"status" usage:"show status"`
+
+	ran []string
+}
+
+type remoteCommand struct {
+	Add    remoteAddCommand `sub_command:"add" usage:"add a remote"`
+	Remove struct {
+		Name string `arg:"0" required:"true"`
+	} `sub_command:"remove" usage:"remove a remote"`
+
+	parent *gitOptions
+}
+
+type remoteAddCommand struct {
+	Name  string `flag:"name" usage:"remote name" required:"true"`
+	Fetch bool   `flag:"f" usage:"fetch after add"`
+	URL   string `arg:"0"`
+}
+
+type statusCommand struct {
+	Short bool `flag:"s" usage:"short format"`
+
+	Args []string
+}
+
+func (opt *gitOptions) StatusCommand() {
+	opt.ran = append(opt.ran, "status")
+}
+
+func (opt *gitOptions) RemoteCommand() {
+	opt.ran = append(opt.ran, "remote")
+}
+
+func (opt *remoteCommand) RemoveCommand() {
+	opt.parent.ran = append(opt.parent.ran, "remove "+opt.Remove.Name)
+}
+
+func (opt *remoteAddCommand) AddCommand() {
+	opt.URL += "!"
+}
+
+func newGitOptions() *gitOptions {
+	opt := &gitOptions{Remote: &remoteCommand{}}
+	opt.Remote.parent = opt
+	return opt
+}
+
+func TestSubCommandTree(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := newGitOptions()
+	ef, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"remote", "add", "-name", "origin", "-f", "git@x"})
+	assert.Nil(err)
+	assert.Equal("origin", opt.Remote.Add.Name)
+	assert.True(opt.Remote.Add.Fetch)
+	assert.Equal("git@x", opt.Remote.Add.URL)
+	ef.RunCommand()
+	assert.Equal("git@x!", opt.Remote.Add.URL)
+	assert.Nil(opt.ran)
+
+	opt = newGitOptions()
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"remote", "remove", "origin"})
+	assert.Nil(err)
+	ef.RunCommand()
+	assert.Equal([]string{"remove origin"}, opt.ran)
+
+	// remote runs its own command without a sub-command
+	opt = newGitOptions()
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"remote"})
+	assert.Nil(err)
+	ef.RunCommand()
+	assert.Equal([]string{"remote"}, opt.ran)
+
+	opt = newGitOptions()
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"status", "-s", "a", "b"})
+	assert.Nil(err)
+	assert.True(opt.Status.Short)
+	assert.Equal([]string{"a", "b"}, opt.Status.Args)
+	ef.RunCommand()
+	assert.Equal([]string{"status"}, opt.ran)
+}
+
+func TestSubCommandTreeError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"remote", "add", "-f"})
+	assert.EqualError(err, "missing required options: -name")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"status", "-name", "x"})
+	assert.EqualError(err, "flag provided but not defined: -name")
+
+	ef, _ := parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"remote", "add", "-name", "x"})
+	assert.Equal("app remote add", ef.currentCommand().sub.currentCommand().sub.flagSet.Name())
+
+	setArgs(t)
+	err = NewEFlag(COMMAND_MODE_SUB_CMD).Parse(&struct {
+		Add struct {
+			A string `arg:"rest"`
+		} `sub_command:"add"`
+	}{})
+	assert.EqualError(err, `sub command add: arg tag "rest" of A requires a slice type`)
+}
+
+func TestSubCommandTreeLeafWithoutMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &struct {
+		Add struct {
+			Name string `flag:"name"`
+		} `sub_command:"add"`
+	}{}
+	ef, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"add", "-name", "x"})
+	assert.Nil(err)
+	assert.Equal("x", opt.Add.Name)
+	assert.Nil(ef.RunCommand())
+}
+
+type showOptions struct {
+	Verbose bool   `flag:"v" usage:"verbose output"`
+	Format  string `flag:"format" usage:"output format" commands:"show,detail" required:"true"`
+	Depth   int    `flag:"depth" usage:"detail depth" commands:"detail"`
+
+	Show   bool `sub_command:"show" usage:"show action"`
+	Detail bool `sub_command:"detail" usage:"show detail action"`
+	Clean  bool `sub_command:"clean" usage:"clean action"`
+}
+
+func TestCommandOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &showOptions{}
+	_, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"detail", "-format", "json", "-depth", "2", "-v"})
+	assert.Nil(err)
+	assert.Equal("json", opt.Format)
+	assert.Equal(2, opt.Depth)
+	assert.True(opt.Verbose)
+
+	// required only for the sub-commands accepting it
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"clean", "-v"})
+	assert.Nil(err)
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"show"})
+	assert.EqualError(err, "missing required options: -format")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"show", "-format", "json", "-depth", "2"})
+	assert.EqualError(err, "flag -depth is not an option of sub command show")
+
+	ef, _ := parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"show", "-h"})
+	assert.Contains(ef.errOutput.String(), "Usage of app show:")
+	assert.Contains(ef.errOutput.String(), "-format")
+	assert.NotContains(ef.errOutput.String(), "-depth")
+
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"help", "detail"})
+	assert.Equal(flag.ErrHelp, err)
+	assert.Contains(ef.errOutput.String(), "Usage of app detail:")
+	assert.Contains(ef.errOutput.String(), "-depth")
+
+	ef, _ = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"help"})
+	assert.Contains(ef.errOutput.String(), "SUB_COMMAND is")
+	assert.Contains(ef.errOutput.String(), "-v")
+	assert.NotContains(ef.errOutput.String(), "-format")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"help", "remote", "add"})
+	assert.Equal(flag.ErrHelp, err)
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"help", "fetch"})
+	assert.EqualError(err, `unknown help topic "fetch"`)
+
+	setArgs(t)
+	err = NewEFlag(COMMAND_MODE_SUB_CMD).Parse(&struct {
+		A bool `flag:"a" commands:"show"`
+	}{})
+	assert.EqualError(err, "commands tag of A refers to unknown sub command show")
+}
+
+func TestGlobalOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := newGitOptions()
+	ef, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"-C", "/tmp", "remote", "add", "-debug", "-name", "x"})
+	assert.Nil(err)
+	assert.Equal("/tmp", opt.Dir)
+	assert.True(opt.Debug)
+	assert.Equal("x", opt.Remote.Add.Name)
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "-debug"}, ef.Source("Debug"))
+
+	opt = newGitOptions()
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"-debug=false", "status", "-C=/tmp", "-s"})
+	assert.Nil(err)
+	assert.False(opt.Debug)
+	assert.Equal("/tmp", opt.Dir)
+	assert.True(opt.Status.Short)
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"-s", "status"})
+	assert.EqualError(err, "flag provided but not defined: -s")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &showOptions{}, []string{"-v", "show"})
+	assert.EqualError(err, "flag -v must follow the sub command name")
+
+	setArgs(t)
+	err = NewEFlag(COMMAND_MODE_SUB_CMD).Parse(&struct {
+		Debug bool `flag:"debug" global:"true"`
+		Sub   struct {
+			Debug bool `flag:"debug"`
+		} `sub_command:"sub"`
+	}{})
+	assert.EqualError(err, "sub command sub: flag -debug of Debug redefined, already declared by Debug")
+}
+
+func TestParentOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	// options of a level may come before the name of a struct sub-command
+	opt := newGitOptions()
+	_, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"-v", "remote", "add", "-name", "x"})
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.Equal("x", opt.Remote.Add.Name)
+
+	opt = newGitOptions()
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"--v", "--C", "/tmp", "status", "--s"}, WithGNUStyle())
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.True(opt.Status.Short)
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"status", "-v"})
+	assert.EqualError(err, "flag provided but not defined: -v")
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"-v"})
+	assert.EqualError(err, "flag -v must follow the sub command name")
+
+	required := &struct {
+		Token string `flag:"token" required:"true"`
+		Run   struct {
+			Fast bool `flag:"fast"`
+		} `sub_command:"run"`
+	}{}
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, required, []string{"run", "-fast"})
+	assert.EqualError(err, "missing required options: -token")
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, required, []string{"-token", "t", "run", "-fast"})
+	assert.Nil(err)
+	assert.Equal("t", required.Token)
+	assert.True(required.Run.Fast)
+}
+
+func TestGlobalOptionsGNU(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &struct {
+		Debug bool   `flag:"debug" flag_short:"d" global:"true"`
+		Dir   string `flag:"dir" flag_short:"C" global:"true"`
+		Level int    `flag:"level" global:"true"`
+		Run   struct {
+			Extract bool `flag:"extract" flag_short:"x"`
+		} `sub_command:"run"`
+	}{}
+	_, err := parseWith(t, COMMAND_MODE_SUB_CMD, opt, []string{"-dC", "/tmp", "--level=2", "run", "-x", "--level", "3"}, WithGNUStyle())
+	assert.Nil(err)
+	assert.True(opt.Debug)
+	assert.Equal("/tmp", opt.Dir)
+	assert.Equal(3, opt.Level)
+	assert.True(opt.Run.Extract)
+
+	// a bad value of a global option after the name is a ValueError too
+	for _, args := range [][]string{{"--level=abc", "run"}, {"run", "--level=abc"}} {
+		_, err = parseWith(t, COMMAND_MODE_SUB_CMD, opt, args, WithGNUStyle())
+		var verr *ValueError
+		assert.ErrorAs(err, &verr)
+		assert.EqualError(err, `invalid value "abc" for -level: expected int: invalid syntax`)
+	}
+}
+
+type runOptions struct {
+	Fail bool `flag:"fail"`
+
+	Build bool `sub_command:"build"`
+	Test  bool `sub_command:"test"`
+	Lint  bool `sub_command:"lint"`
+
+	ctx  context.Context
+	args []string
+}
+
+func (opt *runOptions) BuildCommand() error {
+	if opt.Fail {
+		return NewExitError(3, errors.New("build failed"))
+	}
+	return nil
+}
+
+func (opt *runOptions) TestCommand(ctx context.Context) error {
+	opt.ctx = ctx
+	return nil
+}
+
+func (opt *runOptions) LintCommand(ctx context.Context, args []string) error {
+	opt.args = args
+	if opt.Fail {
+		return fmt.Errorf("lint: %w", errors.New("failed"))
+	}
+	return nil
+}
+
+func TestCommandMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	run := func(args ...string) (*runOptions, error) {
+		setArgs(t, args...)
+		opt := &runOptions{}
+		ef := NewEFlag(COMMAND_MODE_SUB_CMD, WithErrorHandling(flag.ContinueOnError))
+		return opt, ef.ParseAndRunCommandContext(context.WithValue(context.Background(), "k", "v"), opt)
+	}
+
+	_, err := run("build")
+	assert.Nil(err)
+	_, err = run("build", "-fail")
+	assert.EqualError(err, "build failed")
+	assert.Equal(3, ExitCode(err))
+
+	opt, err := run("test")
+	assert.Nil(err)
+	assert.Equal("v", opt.ctx.Value("k"))
+
+	opt, err = run("lint", "-fail", "a", "b")
+	assert.EqualError(err, "lint: failed")
+	assert.Equal(1, ExitCode(err))
+	assert.Equal([]string{"a", "b"}, opt.args)
+
+	_, err = run()
+	assert.EqualError(err, "missing sub command")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"remote", "add"})
+	assert.Equal(2, ExitCode(err))
+	assert.Equal(0, ExitCode(nil))
+	assert.Equal(0, ExitCode(flag.ErrHelp))
+}
+
+func TestExitCodeUsageError(t *testing.T) {
+	assert := assert.New(t)
+
+	var usageErr *UsageError
+	for _, c := range []struct {
+		args  []string
+		msg   string
+		usage bool
+	}{
+		{[]string{"show", "-nope"}, "flag provided but not defined: -nope", true},
+		{[]string{"show", "-age"}, "flag needs an argument: -age", true},
+		{[]string{"bogus"}, `unknown sub command "bogus"`, true},
+		{[]string{"show", "-age=x"}, `invalid value "x" for -age: expected int: invalid syntax`, false},
+	} {
+		_, err := parseWith(t, COMMAND_MODE_SUB_CMD, &struct {
+			Age  int  `flag:"age"`
+			Show bool `sub_command:"show"`
+		}{}, c.args)
+		assert.EqualError(err, c.msg)
+		assert.Equal(2, ExitCode(err), c.args)
+		assert.Equal(c.usage, errors.As(err, &usageErr), c.args)
+	}
+}
+
+type badRunOptions struct {
+	Bad bool `sub_command:"bad"`
+}
+
+func (opt *badRunOptions) BadCommand(args []string) {}
+
+func TestCommandMethodSignature(t *testing.T) {
+	setArgs(t, "bad")
+	err := NewEFlag(COMMAND_MODE_SUB_CMD).Parse(&badRunOptions{})
+	assert.EqualError(t, err, "method BadCommand of *eflag.badRunOptions must be func() error, func(context.Context) error or func(context.Context, []string) error")
+}
+
+type aliasOptions struct {
+	Format string `flag:"format"`
+	Depth  int    `flag:"depth" commands:"list"`
+
+	List   bool `sub_command:"list" aliases:"ls,l" usage:"list items"`
+	Status bool `sub_command:"status" usage:"show status"`
+	Stash  bool `sub_command:"stash" usage:"stash changes"`
+}
+
+func TestCommandAliases(t *testing.T) {
+	assert := assert.New(t)
+
+	ef, err := parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"ls", "-depth", "2"})
+	assert.Nil(err)
+	assert.Equal("list", ef.currentCommand().Name)
+	assert.Equal("list (ls,l)    list items", ef.currentCommand().UsageString())
+
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"lis"}, WithPrefixMatching())
+	assert.Nil(err)
+	assert.Equal("list", ef.currentCommand().Name)
+	ef, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"stat"}, WithPrefixMatching())
+	assert.Nil(err)
+	assert.Equal("status", ef.currentCommand().Name)
+
+	// ambiguous prefix
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"st"}, WithPrefixMatching())
+	assert.EqualError(err, `unknown sub command "st"`)
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"lis"})
+	assert.EqualError(err, `unknown sub command "lis", did you mean 'list'?`)
+}
+
+func TestSuggestions(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"stauts"})
+	assert.EqualError(err, `unknown sub command "stauts", did you mean 'status'?`)
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"deploy"})
+	assert.EqualError(err, `unknown sub command "deploy"`)
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"status", "-fromat", "json"})
+	assert.EqualError(err, "flag provided but not defined: -fromat, did you mean -format?")
+	// options of other sub-commands are not suggested
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"status", "-dept", "1"})
+	assert.EqualError(err, "flag provided but not defined: -dept")
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, &aliasOptions{}, []string{"list", "--dept=1"}, WithGNUStyle())
+	assert.EqualError(err, "flag provided but not defined: --dept, did you mean --depth?")
+
+	_, err = parseWith(t, COMMAND_MODE_SUB_CMD, newGitOptions(), []string{"help", "remote", "ad"})
+	assert.EqualError(err, `unknown help topic "ad", did you mean 'add'?`)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(0, editDistance("show", "show"))
+	assert.Equal(1, editDistance("shwo", "show"))
+	assert.Equal(1, editDistance("sho", "show"))
+	assert.Equal(3, editDistance("", "abc"))
+	assert.Equal(3, editDistance("kitten", "sitting"))
+	assert.Equal("status", suggest("stats", []string{"stash", "status"}))
+	assert.Equal("", suggest("x", []string{"v"}))
+}
@@ -0,0 +1,406 @@
+package eflag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	COMPLETION_COMMAND_NAME  = "completion" // app completion bash
+	COMPLETE_COMMAND_NAME    = "__complete" // app __complete remote add -name ori
+	COMPLETE_METHOD_NAME_KEY = "Complete"   // <Field>Complete(prefix string) []string
+)
+
+// ErrCompletion is returned by Parse after the completion command printed a script.
+var ErrCompletion = errors.New("completion script printed")
+
+// completionNode is a command level of the completion script.
+type completionNode struct {
+	path    string             // eg: app remote add
+	parent  string             // path of the parent level, empty for the program
+	names   []string           // name and aliases of the sub-command
+	words   []string           // flags and sub-commands, eg: -name remote
+	values  []*completionValue // flags with choices or <Field>Complete method
+	dynamic bool               // positional args have <Field>Complete method
+}
+
+// completionValue is the choices of a flag.
+type completionValue struct {
+	flags   []string // eg: --level -l
+	choices []string
+	dynamic bool // choices from app __complete
+}
+
+// PrintCompletion print the completion script of shell to w, one of bash, zsh and fish.
+// The script is built from the options and sub-commands registered by Parse.
+func (e *EFlag) PrintCompletion(w io.Writer, shell string) error {
+	name := filepath.Base(e.flagSet.Name())
+	nodes := e.completionNodes(name, "", nil, "")
+
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion(name, nodes)
+	case "zsh":
+		script = zshCompletion(name, nodes)
+	case "fish":
+		script = fishCompletion(name, nodes)
+	default:
+		return fmt.Errorf("unsupported shell %q, expected bash, zsh or fish", shell)
+	}
+	_, err := io.WriteString(w, script)
+	return err
+}
+
+// completionNodes returns the completion levels of e and its sub-commands.
+// commandName selects the options of a flat sub-command, which has no sub-commands.
+func (e *EFlag) completionNodes(path, parent string, names []string, commandName string) []*completionNode {
+	node := &completionNode{path: path, parent: parent, names: names}
+	e.flagSet.VisitAll(func(fl *flag.Flag) {
+		f := e.lookupFlag(fl.Name)
+		if f == nil {
+			node.words = append(node.words, e.flagDisplayName(fl.Name))
+			return
+		}
+		if f.name != fl.Name || !e.isAllowedIn(f, commandName) {
+			return // short flag, added with its flag
+		}
+		flags := []string{e.flagDisplayName(f.name)}
+		if f.short != "" {
+			flags = append(flags, e.flagDisplayName(f.short))
+		}
+		node.words = append(node.words, flags...)
+		if f.complete.IsValid() || len(f.choices) > 0 {
+			node.values = append(node.values, &completionValue{flags: flags, choices: f.choices, dynamic: f.complete.IsValid()})
+		}
+	})
+	for _, af := range e.positionals {
+		node.dynamic = node.dynamic || af.complete.IsValid()
+	}
+
+	nodes := []*completionNode{node}
+	if commandName != "" || !e.isMode(COMMAND_MODE_SUB_CMD) {
+		return nodes
+	}
+	for _, cmd := range e.commandList {
+		node.words = append(node.words, cmd.names()...)
+		if cmd.sub != nil {
+			nodes = append(nodes, cmd.sub.completionNodes(path+" "+cmd.Name, path, cmd.names(), "")...)
+		} else {
+			nodes = append(nodes, e.completionNodes(path+" "+cmd.Name, path, cmd.names(), cmd.Name)...)
+		}
+	}
+	return nodes
+}
+
+// completionCommand print the completion script if the command-line is the
+// completion command, eg: app completion bash
+func (e *EFlag) completionCommand(args []string) (bool, error) {
+	if !e.config.CompletionCommand || len(args) == 0 || args[0] != COMPLETION_COMMAND_NAME {
+		return false, nil
+	}
+	if len(args) < 2 {
+		return true, e.failf("%s needs a shell: bash, zsh or fish", COMPLETION_COMMAND_NAME)
+	}
+	if err := e.PrintCompletion(os.Stdout, args[1]); err != nil {
+		return true, e.failf("%v", err)
+	}
+	return true, e.done(ErrCompletion)
+}
+
+var completeMethodType = reflect.TypeOf(func(string) []string { return nil })
+
+// completeMethod returns method name of rv if it is declared, checking it is func(prefix string) []string.
+func completeMethod(rv reflect.Value, name string) (reflect.Value, error) {
+	rm := rv.MethodByName(name)
+	if rm.IsValid() && rm.Type() != completeMethodType {
+		return reflect.Value{}, fmt.Errorf("method %s of %s must be func(prefix string) []string", name, rv.Type())
+	}
+	return rm, nil
+}
+
+// complete print the candidates of the last arg, one per line, args being
+// the command-line after the program name, eg: remote add -name ori
+// The sub-command and the flag or positional arg under the cursor are resolved
+// from the args before, and the candidates come from <Field>Complete methods,
+// oneof choices, flag names or sub-command names.
+func (e *EFlag) complete(w io.Writer, args []string) {
+	cur := ""
+	if len(args) > 0 {
+		cur, args = args[len(args)-1], args[:len(args)-1]
+	}
+
+	target, commandName, npos := e, "", 0
+	var valueOf *flagField // flag taking the value under the cursor
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return // passed through
+		}
+		if len(arg) > 1 && arg[0] == '-' {
+			name, _, hasValue := splitOption(strings.TrimPrefix(arg[1:], "-"))
+			fl := target.flagSet.Lookup(name)
+			if fl != nil && !hasValue && !isBoolValue(fl.Value) {
+				if i == len(args)-1 {
+					valueOf = target.lookupFlag(name)
+				}
+				i++
+			}
+			continue
+		}
+		if commandName == "" {
+			if cmd := target.lookupCommand(target.resolveCommand(arg)); cmd != nil {
+				if cmd.sub != nil {
+					target, npos = cmd.sub, 0
+				} else {
+					commandName = cmd.Name
+				}
+				continue
+			}
+		}
+		npos++
+	}
+
+	var candidates []string
+	prefix := ""
+	switch {
+	case valueOf != nil:
+		candidates = valueOf.completions(cur)
+	case strings.HasPrefix(cur, "-") && strings.Contains(cur, "="):
+		// -name=prefix
+		i := strings.Index(cur, "=")
+		if f := target.lookupFlag(strings.TrimLeft(cur[:i], "-")); f != nil {
+			prefix, cur = cur[:i+1], cur[i+1:]
+			candidates = f.completions(cur)
+		}
+	case strings.HasPrefix(cur, "-"):
+		target.flagSet.VisitAll(func(fl *flag.Flag) {
+			if f := target.lookupFlag(fl.Name); f == nil || target.isAllowedIn(f, commandName) {
+				candidates = append(candidates, target.flagDisplayName(fl.Name))
+			}
+		})
+	case commandName == "" && target.isMode(COMMAND_MODE_SUB_CMD) && len(target.commandList) > 0:
+		for _, cmd := range target.commandList {
+			candidates = append(candidates, cmd.names()...)
+		}
+	default:
+		if af := target.positionalAt(npos); af != nil {
+			candidates = af.completions(cur)
+		}
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, cur) {
+			fmt.Fprintln(w, prefix+candidate)
+		}
+	}
+}
+
+// completions returns the candidates of the value of f, from its <Field>Complete method or its choices.
+func (f *flagField) completions(prefix string) []string {
+	if f.complete.IsValid() {
+		return f.complete.Call([]reflect.Value{reflect.ValueOf(prefix)})[0].Interface().([]string)
+	}
+	return f.choices
+}
+
+// positionalAt returns the positional field of index, nil if none.
+func (e *EFlag) positionalAt(index int) *argField {
+	for _, af := range e.positionals {
+		if af.index == index || af.rest && af.index <= index {
+			return af
+		}
+	}
+	return nil
+}
+
+var nonIdentRe = regexp.MustCompile(`[^A-Za-z0-9_]`)
+
+// completionFunc returns the shell function name of program name.
+func completionFunc(name string) string {
+	return "_" + nonIdentRe.ReplaceAllString(name, "_")
+}
+
+// shellQuote quote s for sh-like shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellWords quote each word and join them with spaces.
+func shellWords(words []string) string {
+	quoted := make([]string, 0, len(words))
+	for _, word := range words {
+		quoted = append(quoted, shellQuote(word))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// pathPatterns returns the case patterns of entering node from its parent, eg: "app remote"|"app rm"
+func (node *completionNode) pathPatterns(sep string) string {
+	patterns := make([]string, 0, len(node.names))
+	for _, name := range node.names {
+		patterns = append(patterns, shellQuote(node.parent+" "+name))
+	}
+	return strings.Join(patterns, sep)
+}
+
+// valuePatterns returns the case patterns of a flag of value under node, eg: "app -level"|"app -l"
+func (node *completionNode) valuePatterns(value *completionValue, sep string) string {
+	patterns := make([]string, 0, len(value.flags))
+	for _, fl := range value.flags {
+		patterns = append(patterns, shellQuote(node.path+" "+fl))
+	}
+	return strings.Join(patterns, sep)
+}
+
+func bashCompletion(name string, nodes []*completionNode) string {
+	var b strings.Builder
+	fn := completionFunc(name)
+	fmt.Fprintf(&b, "# bash completion for %s, generated by eflag\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cur prev cmdpath word words i\n")
+	b.WriteString("    cur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	b.WriteString("    prev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	fmt.Fprintf(&b, "    cmdpath=%s\n", shellQuote(name))
+	b.WriteString("    for ((i = 1; i < COMP_CWORD; i++)); do\n")
+	b.WriteString("        word=\"${COMP_WORDS[i]}\"\n")
+	b.WriteString("        case \"$cmdpath $word\" in\n")
+	for _, node := range nodes[1:] {
+		fmt.Fprintf(&b, "            %s) cmdpath=%s ;;\n", node.pathPatterns("|"), shellQuote(node.path))
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath $prev\" in\n")
+	for _, node := range nodes {
+		for _, value := range node.values {
+			if value.dynamic {
+				fmt.Fprintf(&b, "        %s) %s_dynamic; return ;;\n", node.valuePatterns(value, "|"), fn)
+				continue
+			}
+			fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W %s -- \"$cur\")); return ;;\n",
+				node.valuePatterns(value, "|"), shellQuote(strings.Join(value.choices, " ")))
+		}
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		if node.dynamic {
+			fmt.Fprintf(&b, "        %s) [[ $cur != -* ]] && { %s_dynamic; return; }; words=%s ;;\n",
+				shellQuote(node.path), fn, shellQuote(strings.Join(node.words, " ")))
+			continue
+		}
+		fmt.Fprintf(&b, "        %s) words=%s ;;\n", shellQuote(node.path), shellQuote(strings.Join(node.words, " ")))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "%s_dynamic() {\n", fn)
+	b.WriteString("    local IFS=$'\\n'\n")
+	fmt.Fprintf(&b, "    COMPREPLY=($(\"${COMP_WORDS[0]}\" %s \"${COMP_WORDS[@]:1:COMP_CWORD-1}\" \"${COMP_WORDS[COMP_CWORD]}\" 2>/dev/null))\n", COMPLETE_COMMAND_NAME)
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o default -F %s %s\n", fn, name)
+	return b.String()
+}
+
+func zshCompletion(name string, nodes []*completionNode) string {
+	var b strings.Builder
+	fn := completionFunc(name)
+	fmt.Fprintf(&b, "#compdef %s\n", name)
+	fmt.Fprintf(&b, "# zsh completion for %s, generated by eflag\n", name)
+	fmt.Fprintf(&b, "%s() {\n", fn)
+	b.WriteString("    local cmdpath word i\n")
+	fmt.Fprintf(&b, "    cmdpath=%s\n", shellQuote(name))
+	b.WriteString("    for ((i = 2; i < CURRENT; i++)); do\n")
+	b.WriteString("        word=\"${words[i]}\"\n")
+	b.WriteString("        case \"$cmdpath $word\" in\n")
+	for _, node := range nodes[1:] {
+		fmt.Fprintf(&b, "            %s) cmdpath=%s ;;\n", node.pathPatterns("|"), shellQuote(node.path))
+	}
+	b.WriteString("        esac\n")
+	b.WriteString("    done\n")
+	b.WriteString("    case \"$cmdpath ${words[CURRENT-1]}\" in\n")
+	for _, node := range nodes {
+		for _, value := range node.values {
+			if value.dynamic {
+				fmt.Fprintf(&b, "        %s) %s_dynamic; return ;;\n", node.valuePatterns(value, "|"), fn)
+				continue
+			}
+			fmt.Fprintf(&b, "        %s) compadd -- %s; return ;;\n", node.valuePatterns(value, "|"), shellWords(value.choices))
+		}
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    case \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		if node.dynamic {
+			fmt.Fprintf(&b, "        %s) [[ ${words[CURRENT]} != -* ]] && { %s_dynamic; return }; compadd -- %s ;;\n",
+				shellQuote(node.path), fn, shellWords(node.words))
+			continue
+		}
+		fmt.Fprintf(&b, "        %s) compadd -- %s ;;\n", shellQuote(node.path), shellWords(node.words))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    _files\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "%s_dynamic() {\n", fn)
+	fmt.Fprintf(&b, "    compadd -- ${(f)\"$(${words[1]} %s \"${(@)words[2,CURRENT]}\" 2>/dev/null)\"}\n", COMPLETE_COMMAND_NAME)
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "compdef %s %s\n", fn, name)
+	return b.String()
+}
+
+func fishCompletion(name string, nodes []*completionNode) string {
+	var b strings.Builder
+	fn := "_" + completionFunc(name)
+	fmt.Fprintf(&b, "# fish completion for %s, generated by eflag\n", name)
+	fmt.Fprintf(&b, "function %s\n", fn)
+	b.WriteString("    set -l words (commandline -opc)\n")
+	b.WriteString("    set -l prog $words[1]\n")
+	b.WriteString("    set -l prev $words[-1]\n")
+	b.WriteString("    set -l cur (commandline -ct)\n")
+	b.WriteString("    set -e words[1]\n")
+	fmt.Fprintf(&b, "    set -l cmdpath %s\n", shellQuote(name))
+	b.WriteString("    for word in $words\n")
+	b.WriteString("        switch \"$cmdpath $word\"\n")
+	for _, node := range nodes[1:] {
+		fmt.Fprintf(&b, "            case %s\n", node.pathPatterns(" "))
+		fmt.Fprintf(&b, "                set cmdpath %s\n", shellQuote(node.path))
+	}
+	b.WriteString("        end\n")
+	b.WriteString("    end\n")
+	b.WriteString("    switch \"$cmdpath $prev\"\n")
+	dynamic := fmt.Sprintf("$prog %s $words \"$cur\" 2>/dev/null", COMPLETE_COMMAND_NAME)
+	for _, node := range nodes {
+		for _, value := range node.values {
+			fmt.Fprintf(&b, "        case %s\n", node.valuePatterns(value, " "))
+			if value.dynamic {
+				fmt.Fprintf(&b, "            %s\n", dynamic)
+			} else {
+				fmt.Fprintf(&b, "            printf '%%s\\n' %s\n", shellWords(value.choices))
+			}
+			b.WriteString("            return\n")
+		}
+	}
+	b.WriteString("    end\n")
+	b.WriteString("    switch \"$cmdpath\"\n")
+	for _, node := range nodes {
+		fmt.Fprintf(&b, "        case %s\n", shellQuote(node.path))
+		if node.dynamic {
+			b.WriteString("            if not string match -q -- '-*' \"$cur\"\n")
+			fmt.Fprintf(&b, "                %s\n", dynamic)
+			b.WriteString("                return\n")
+			b.WriteString("            end\n")
+		}
+		fmt.Fprintf(&b, "            printf '%%s\\n' %s\n", shellWords(node.words))
+	}
+	b.WriteString("    end\n")
+	b.WriteString("end\n")
+	fmt.Fprintf(&b, "complete -c %s -a '(%s)'\n", name, fn)
+	return b.String()
+}
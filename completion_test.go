@@ -0,0 +1,162 @@
+package eflag
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type completionOptions struct {
+	Level  string `flag:"level" flag_short:"l" oneof:"debug|info"`
+	Format string `flag:"format" commands:"list"`
+
+	List   bool `sub_command:"list" aliases:"ls"`
+	Remote struct {
+		Name string `flag:"name"`
+	} `sub_command:"remote"`
+}
+
+func printCompletion(t *testing.T, shell string, options ...EFlagOption) (string, error) {
+	setArgs(t, "list")
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD, options...)
+	assert.Nil(t, ef.Parse(&completionOptions{}))
+	var b strings.Builder
+	err := ef.PrintCompletion(&b, shell)
+	return b.String(), err
+}
+
+func TestCompletionBash(t *testing.T) {
+	assert := assert.New(t)
+
+	script, err := printCompletion(t, "bash")
+	assert.Nil(err)
+	assert.Contains(script, "'app list'|'app ls') cmdpath='app list' ;;")
+	assert.Contains(script, "'app remote') cmdpath='app remote' ;;")
+	assert.Contains(script, "'app -level'|'app -l') COMPREPLY=($(compgen -W 'debug info' -- \"$cur\")); return ;;")
+	assert.Contains(script, "'app') words='-level -l list ls remote' ;;")
+	assert.Contains(script, "'app list') words='-format -level -l' ;;")
+	assert.Contains(script, "'app remote') words='-name' ;;")
+	assert.Contains(script, "complete -o default -F _app app")
+
+	script, err = printCompletion(t, "bash", WithGNUStyle())
+	assert.Nil(err)
+	assert.Contains(script, "'app --level'|'app -l') COMPREPLY")
+}
+
+func TestCompletionZshFish(t *testing.T) {
+	assert := assert.New(t)
+
+	script, err := printCompletion(t, "zsh")
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(script, "#compdef app\n"))
+	assert.Contains(script, "'app -level'|'app -l') compadd -- 'debug' 'info'; return ;;")
+	assert.Contains(script, "'app list') compadd -- '-format' '-level' '-l' ;;")
+
+	script, err = printCompletion(t, "fish")
+	assert.Nil(err)
+	assert.Contains(script, "case 'app list' 'app ls'\n                set cmdpath 'app list'")
+	assert.Contains(script, "case 'app -level' 'app -l'\n            printf '%s\\n' 'debug' 'info'")
+	assert.Contains(script, "complete -c app -a '(__app)'")
+
+	_, err = printCompletion(t, "csh")
+	assert.EqualError(err, `unsupported shell "csh", expected bash, zsh or fish`)
+}
+
+func TestCompletionCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "completion", "bash")
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD, WithCompletionCommand(), WithErrorHandling(flag.ContinueOnError))
+	err := ef.Parse(&completionOptions{})
+	assert.Equal(ErrCompletion, err)
+	assert.Equal(0, ExitCode(err))
+
+	setArgs(t, "completion")
+	ef = NewEFlag(COMMAND_MODE_OPTION, WithCompletionCommand(), WithErrorHandling(flag.ContinueOnError))
+	err = ef.Parse(&struct {
+		Verbose bool `flag:"v"`
+	}{})
+	assert.EqualError(err, "completion needs a shell: bash, zsh or fish")
+
+	// without WithCompletionCommand, completion is an unknown sub-command
+	setArgs(t, "completion", "bash")
+	ef = NewEFlag(COMMAND_MODE_SUB_CMD, WithErrorHandling(flag.ContinueOnError))
+	assert.EqualError(ef.Parse(&completionOptions{}), `unknown sub command "completion"`)
+}
+
+type dynamicOptions struct {
+	Cluster string `flag:"cluster" flag_short:"c" global:"true"`
+	Level   string `flag:"level" oneof:"debug|info"`
+	Verbose bool   `flag:"v"`
+
+	Deploy deployCommand `sub_command:"deploy" aliases:"d"`
+	Status bool          `sub_command:"status"`
+}
+
+type deployCommand struct {
+	Force  bool     `flag:"force"`
+	Target string   `arg:"0"`
+	Files  []string `arg:"rest"`
+}
+
+func (opt *dynamicOptions) ClusterComplete(prefix string) []string {
+	return []string{"prod", "prod-eu", "staging"}
+}
+
+func (opt *deployCommand) TargetComplete(prefix string) []string {
+	return []string{"web", "worker", prefix + "!"}
+}
+
+func completeArgs(t *testing.T, args ...string) string {
+	setArgs(t)
+	opt := &dynamicOptions{}
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD)
+	assert.Nil(t, ef.register(reflect.ValueOf(opt)))
+	var b strings.Builder
+	ef.complete(&b, args)
+	return b.String()
+}
+
+func TestComplete(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("prod\nprod-eu\n", completeArgs(t, "-cluster", "pr"))
+	assert.Equal("prod\nprod-eu\nstaging\n", completeArgs(t, "deploy", "-c", ""))
+	assert.Equal("--cluster=staging\n", completeArgs(t, "--cluster=s"))
+	assert.Equal("info\n", completeArgs(t, "-v", "-level", "i"))
+	assert.Equal("deploy\nd\nstatus\n", completeArgs(t, "-v", ""))
+	assert.Equal("-level\n", completeArgs(t, "-l"))
+	assert.Equal("-c\n-cluster\n-force\n", completeArgs(t, "d", "-"))
+
+	// positional args are completed by their field
+	assert.Equal("web\nworker\nw!\n", completeArgs(t, "deploy", "-force", "w"))
+	assert.Equal("", completeArgs(t, "deploy", "web", "w"))
+	assert.Equal("", completeArgs(t, "deploy", "--", "w"))
+	assert.Equal("deploy\nd\nstatus\n", completeArgs(t))
+}
+
+func TestCompleteScript(t *testing.T) {
+	setArgs(t, "status")
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD)
+	assert.Nil(t, ef.Parse(&dynamicOptions{}))
+	var b strings.Builder
+	assert.Nil(t, ef.PrintCompletion(&b, "bash"))
+	assert.Contains(t, b.String(), "'app -cluster'|'app -c') _app_dynamic; return ;;")
+	assert.Contains(t, b.String(), "'app deploy -cluster'|'app deploy -c') _app_dynamic; return ;;")
+	assert.Contains(t, b.String(), `COMPREPLY=($("${COMP_WORDS[0]}" __complete "${COMP_WORDS[@]:1:COMP_CWORD-1}" "${COMP_WORDS[COMP_CWORD]}" 2>/dev/null))`)
+
+	setArgs(t)
+	err := NewEFlag(COMMAND_MODE_SUB_CMD).Parse(&badCompleteOptions{})
+	assert.EqualError(t, err, "method NameComplete of *eflag.badCompleteOptions must be func(prefix string) []string")
+}
+
+type badCompleteOptions struct {
+	Name string `flag:"name"`
+}
+
+func (opt *badCompleteOptions) NameComplete() []string {
+	return nil
+}
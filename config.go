@@ -0,0 +1,209 @@
+package eflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownConfigKey = errors.New("unknown option")
+)
+
+// ConfigFileError is returned when the config file can not be applied.
+type ConfigFileError struct {
+	Path string
+	Key  string
+	Err  error
+}
+
+func (e *ConfigFileError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("config file %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("config file %s: key %q: %v", e.Path, e.Key, e.Err)
+}
+
+func (e *ConfigFileError) Unwrap() error {
+	return e.Err
+}
+
+// configPath returns the config file path.
+// The config flag in args takes precedence over WithConfigFile.
+func (e *EFlag) configPath(args []string) string {
+	if e.config.ConfigFlag != "" {
+		if path, ok := lookupArg(args, e.config.ConfigFlag); ok {
+			return path
+		}
+	}
+	return e.config.ConfigFile
+}
+
+// loadConfig set options from the JSON config file, keyed by flag name.
+func (e *EFlag) loadConfig(args []string) error {
+	path := e.configPath(args)
+	if path == "" {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return &ConfigFileError{Path: path, Err: err}
+	}
+	root := map[string]json.RawMessage{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return &ConfigFileError{Path: path, Err: err}
+	}
+	doc := map[string]json.RawMessage{}
+	e.flattenConfig("", root, doc)
+
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		f := e.lookupField(key)
+		if f == nil {
+			return &ConfigFileError{Path: path, Key: key, Err: ErrUnknownConfigKey}
+		}
+		sval, err := jsonFlagString(doc[key], f.typ, f.val.itemSep, f.val.mapSep)
+		if err == nil {
+			err = f.set(sval, Source{Kind: SOURCE_CONFIG_FILE, Name: path})
+		}
+		if err != nil {
+			return &ConfigFileError{Path: path, Key: key, Err: err}
+		}
+	}
+	return nil
+}
+
+// flattenConfig flatten objects of nested structs to dotted keys,
+// eg: {"db": {"host": "localhost"}} => {"db.host": "localhost"}
+func (e *EFlag) flattenConfig(prefix string, doc, out map[string]json.RawMessage) {
+	for key, raw := range doc {
+		key = prefix + key
+		var sub map[string]json.RawMessage
+		if e.lookupField(key) == nil && json.Unmarshal(raw, &sub) == nil {
+			e.flattenConfig(key+".", sub, out)
+			continue
+		}
+		out[key] = raw
+	}
+}
+
+// jsonFlagString convert JSON value to the command-line form of typ,
+// eg: ["a", "b"] => a@b, {"k": 1} => k=1
+// Items containing a separator are quoted.
+func jsonFlagString(raw json.RawMessage, typ reflect.Type, itemSep, mapSep string) (string, error) {
+	switch {
+	case IsCustomType(typ):
+		return jsonAtomString(raw, reflect.String)
+	case typ.Kind() == reflect.Ptr:
+		return jsonFlagString(raw, typ.Elem(), itemSep, mapSep)
+	case typ.Kind() == reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return "", jsonTypeError(raw, typ)
+		}
+		strs := make([]string, 0, len(items))
+		for _, item := range items {
+			s, err := jsonAtomString(item, jsonKind(typ.Elem()))
+			if err != nil {
+				return "", err
+			}
+			strs = append(strs, quoteItem(s, itemSep))
+		}
+		return strings.Join(strs, itemSep), nil
+	case typ.Kind() == reflect.Map:
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return "", jsonTypeError(raw, typ)
+		}
+		strs := make([]string, 0, len(items))
+		for k, item := range items {
+			s, err := jsonAtomString(item, jsonKind(typ.Elem()))
+			if err != nil {
+				return "", err
+			}
+			strs = append(strs, quoteItem(k, itemSep, mapSep)+mapSep+quoteItem(s, itemSep))
+		}
+		sort.Strings(strs)
+		return strings.Join(strs, itemSep), nil
+	}
+	return jsonAtomString(raw, jsonKind(typ))
+}
+
+// jsonKind returns the kind of JSON scalar expected for typ,
+// time.Duration and custom types are given in string form.
+func jsonKind(typ reflect.Type) reflect.Kind {
+	if typ == durationType || IsCustomType(typ) {
+		return reflect.String
+	}
+	return typ.Kind()
+}
+
+// jsonAtomString convert JSON scalar to string, checking it matches kind.
+func jsonAtomString(raw json.RawMessage, kind reflect.Kind) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	switch kind {
+	case reflect.String:
+		var s string
+		if err := json.Unmarshal(raw, &s); err == nil {
+			return s, nil
+		}
+	case reflect.Bool:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err == nil {
+			return strconv.FormatBool(b), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if _, err := strconv.ParseInt(string(raw), 10, 64); err == nil {
+			return string(raw), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if _, err := strconv.ParseUint(string(raw), 10, 64); err == nil {
+			return string(raw), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(string(raw), 64); err == nil {
+			return string(raw), nil
+		}
+	}
+	return "", fmt.Errorf("cannot use %s as %s", raw, kind)
+}
+
+func jsonTypeError(raw json.RawMessage, typ reflect.Type) error {
+	return fmt.Errorf("cannot use %s as %s", bytes.TrimSpace(raw), typ)
+}
+
+// lookupArg returns the value of flag name in args without parsing them.
+// Both -name=value and -name value forms are recognized.
+func lookupArg(args []string, name string) (string, bool) {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+		arg = arg[1:]
+		if arg[0] == '-' {
+			arg = arg[1:]
+		}
+		if arg == name && i+1 < len(args) {
+			return args[i+1], true
+		}
+		if strings.HasPrefix(arg, name+"=") {
+			return arg[len(name)+1:], true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,92 @@
+package eflag
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type configOptions struct {
+	Name      string            `flag:"name" default:"lycb" usage:"user name"`
+	Age       int               `flag:"age" default:"23" usage:"user age"`
+	Man       bool              `flag:"man" usage:"user sex"`
+	Salary    float64           `flag:"salary" usage:"user salary"`
+	Sleep     time.Duration     `flag:"sleep" default:"10ms" usage:"sleep duration"`
+	Addresses []string          `flag:"addr" usage:"home address"`
+	Headers   map[string]string `flag:"header" usage:"request header"`
+}
+
+// writeConfig write content to a temporary config file.
+func writeConfig(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigFile(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{
+		"name": "lisi",
+		"age": 30,
+		"man": true,
+		"salary": 1200.5,
+		"sleep": "1s",
+		"addr": ["beijing", "linzhou"],
+		"header": {"lang": "golang", "age": "30"}
+	}`)
+	setArgs(t, "-age=31")
+
+	opt := &configOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path)).Parse(opt))
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(31, opt.Age)
+	assert.True(opt.Man)
+	assert.Equal(1200.5, opt.Salary)
+	assert.Equal(time.Second, opt.Sleep)
+	assert.Equal([]string{"beijing", "linzhou"}, opt.Addresses)
+	assert.Equal(map[string]string{"lang": "golang", "age": "30"}, opt.Headers)
+}
+
+func TestConfigFlag(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"name": "lisi"}`)
+	setArgs(t, "--config", path, "-age=31")
+
+	opt := &configOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION, WithConfigFlag("config")).Parse(opt))
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(31, opt.Age)
+}
+
+func TestConfigFileError(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string]string{
+		`{"unknown": 1}`:    "unknown",
+		`{"age": "abc"}`:    "age",
+		`{"age": 1.5}`:      "age",
+		`{"addr": "a"}`:     "addr",
+		`{"header": [1]}`:   "header",
+		`{"man": "true"}`:   "man",
+		`{"sleep": 100000}`: "sleep",
+	}
+	for content, key := range cases {
+		setArgs(t)
+		path := writeConfig(t, content)
+		err := NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path)).Parse(&configOptions{})
+
+		var cerr *ConfigFileError
+		if assert.True(errors.As(err, &cerr), content) {
+			assert.Equal(path, cerr.Path)
+			assert.Equal(key, cerr.Key)
+		}
+	}
+}
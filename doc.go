@@ -0,0 +1,203 @@
+package eflag
+
+import (
+	"flag"
+	"reflect"
+	"strings"
+)
+
+const (
+	DESCRIPTION_METHOD_NAME_KEY = "Description" // Description() string, <Name>Description() string
+	EXAMPLES_METHOD_NAME_KEY    = "Examples"    // Examples() []string, <Name>Examples() []string
+)
+
+// docCommand is the documentation of a command level, built from the same
+// metadata as Usage, for the man page and Markdown generators.
+type docCommand struct {
+	path        string // eg: app remote add
+	name        string // sub-command name, empty for the program
+	aliases     []string
+	usage       string
+	description string
+	synopsis    string   // eg: [OPTIONS] SRC DST FILES...
+	examples    []string // command lines
+	options     []*docOption
+	args        []*docOption // positional args
+	commands    []*docCommand
+}
+
+// docOption is the documentation of an option or a positional arg.
+type docOption struct {
+	names       []string // eg: -n --name
+	typ         string
+	def         string
+	env         string
+	choices     []string
+	usage       string
+	description string
+	required    bool
+	isBool      bool // takes no value
+}
+
+// docCommand returns the documentation of e and its sub-commands.
+// commandName selects the options specific to a flat sub-command.
+func (e *EFlag) docCommand(path, commandName string) *docCommand {
+	doc := &docCommand{path: path, synopsis: e.synopsis(commandName)}
+	e.flagSet.VisitAll(func(fl *flag.Flag) {
+		f := e.lookupFlag(fl.Name)
+		if f == nil {
+			if commandName == "" {
+				// flags not bound to a field, eg: the config flag
+				doc.options = append(doc.options, &docOption{names: []string{e.flagDisplayName(fl.Name)}, typ: "string", def: fl.DefValue, usage: fl.Usage})
+			}
+			return
+		}
+		if f.name != fl.Name || e.isInherited(f) || !e.isAllowedIn(f, commandName) || commandName != "" && len(f.commands) == 0 {
+			return // short flag, documented by the parent command, or not an option of the command
+		}
+		opt := f.docOption(fl.DefValue)
+		opt.names = []string{e.flagDisplayName(f.name)}
+		if f.short != "" {
+			opt.names = append([]string{e.flagDisplayName(f.short)}, opt.names...)
+		}
+		doc.options = append(doc.options, opt)
+	})
+	if commandName != "" {
+		return doc
+	}
+
+	for _, af := range e.positionals {
+		arg := af.docOption(af.value.String())
+		arg.names = []string{af.name}
+		doc.args = append(doc.args, arg)
+	}
+	if !e.isMode(COMMAND_MODE_SUB_CMD) {
+		return doc
+	}
+	for _, cmd := range e.commandList {
+		var sub *docCommand
+		if cmd.sub != nil {
+			sub = cmd.sub.docCommand(path+" "+cmd.Name, "")
+		} else {
+			sub = e.docCommand(path+" "+cmd.Name, cmd.Name)
+		}
+		sub.name, sub.aliases, sub.usage = cmd.Name, cmd.Aliases, cmd.Usage
+		sub.description, sub.examples = cmd.description(), cmd.examples()
+		doc.commands = append(doc.commands, sub)
+	}
+	return doc
+}
+
+func (f *flagField) docOption(def string) *docOption {
+	typ := f.typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	opt := &docOption{
+		typ:         typ.String(),
+		env:         f.env,
+		choices:     f.choices,
+		usage:       f.usage,
+		description: f.description,
+		required:    f.required,
+		isBool:      isBoolFlag(f.typ),
+	}
+	if !(def == "" || opt.isBool && def == "false") {
+		opt.def = def
+	}
+	return opt
+}
+
+// isInherited report whether f is a global option of a parent command.
+func (e *EFlag) isInherited(f *flagField) bool {
+	for _, g := range e.globals {
+		if g == f {
+			return true
+		}
+	}
+	return false
+}
+
+// synopsis returns the args of the usage line, eg: [OPTIONS] SRC DST FILES...
+func (e *EFlag) synopsis(commandName string) string {
+	if commandName == "" && e.isMode(COMMAND_MODE_SUB_CMD) && len(e.commandList) > 0 {
+		return "[OPTIONS] COMMAND"
+	}
+	if len(e.positionals) > 0 {
+		return "[OPTIONS] " + e.positionalUsage()
+	}
+	return "[OPTIONS]"
+}
+
+// description returns the result of Description method of the options struct.
+func (e *EFlag) description() string {
+	return callDescribeMethod(e.recv, DESCRIPTION_METHOD_NAME_KEY)
+}
+
+// description returns the long description of c from the Description method
+// of the sub-command struct, or the <Name>Description method.
+func (c *Command) description() string {
+	if c.sub != nil {
+		if s := c.sub.description(); s != "" {
+			return s
+		}
+	}
+	return callDescribeMethod(c.rv, c.MethodName+DESCRIPTION_METHOD_NAME_KEY)
+}
+
+// callDescribeMethod returns the result of func() string method name of rv, empty if none.
+func callDescribeMethod(rv reflect.Value, name string) string {
+	if !rv.IsValid() {
+		return ""
+	}
+	rm := rv.MethodByName(name)
+	if !rm.IsValid() || rm.Type().NumIn() != 0 || rm.Type().NumOut() != 1 || rm.Type().Out(0).Kind() != reflect.String {
+		return ""
+	}
+	return rm.Call(nil)[0].String()
+}
+
+// examples returns the result of Examples method of the options struct.
+func (e *EFlag) examples() []string {
+	return callExamplesMethod(e.recv, EXAMPLES_METHOD_NAME_KEY)
+}
+
+// examples returns the examples of c from the Examples method of the
+// sub-command struct, or the <Name>Examples method.
+func (c *Command) examples() []string {
+	if c.sub != nil {
+		if examples := c.sub.examples(); len(examples) > 0 {
+			return examples
+		}
+	}
+	return callExamplesMethod(c.rv, c.MethodName+EXAMPLES_METHOD_NAME_KEY)
+}
+
+// callExamplesMethod returns the result of func() []string method name of rv, nil if none.
+func callExamplesMethod(rv reflect.Value, name string) []string {
+	if !rv.IsValid() {
+		return nil
+	}
+	rm := rv.MethodByName(name)
+	if !rm.IsValid() || rm.Type().NumIn() != 0 || rm.Type().NumOut() != 1 || rm.Type().Out(0) != stringSliceType {
+		return nil
+	}
+	return rm.Call(nil)[0].Interface().([]string)
+}
+
+// splitDescription split the first line of description as the summary.
+func splitDescription(description string) (summary, rest string) {
+	description = strings.TrimSpace(description)
+	if i := strings.Index(description, "\n"); i >= 0 {
+		return strings.TrimSpace(description[:i]), strings.TrimSpace(description[i+1:])
+	}
+	return description, ""
+}
+
+// walk calls fn for doc and its sub-commands, depth first.
+func (doc *docCommand) walk(fn func(*docCommand)) {
+	fn(doc)
+	for _, sub := range doc.commands {
+		sub.walk(fn)
+	}
+}
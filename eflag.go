@@ -3,6 +3,7 @@
 package eflag
 
 import (
+	"context"
 	"errors"
 	"flag"
 	"fmt"
@@ -11,6 +12,10 @@ import (
 	"strings"
 )
 
+const (
+	NESTED_PREFIX_TAG_KEY = "prefix"
+)
+
 var (
 	defaultEFlag = NewEFlag(COMMAND_MODE_OPTION)
 	// defaultEFlag = NewEFlag(COMMAND_MODE_SUB_CMD)
@@ -21,10 +26,17 @@ func Parse(v interface{}) error {
 	return defaultEFlag.Parse(v)
 }
 
+// ParseAndRunCommand parse command-line options to v, then run the command,
+// returning the error of either.
 func ParseAndRunCommand(v interface{}) error {
 	return defaultEFlag.ParseAndRunCommand(v)
 }
 
+// ParseAndRunCommandContext is like ParseAndRunCommand, passing ctx to the command method.
+func ParseAndRunCommandContext(ctx context.Context, v interface{}) error {
+	return defaultEFlag.ParseAndRunCommandContext(ctx, v)
+}
+
 // EFlag
 type EFlag struct {
 	flagSet *flag.FlagSet
@@ -35,6 +47,15 @@ type EFlag struct {
 	commandMode CommandMode
 	commandName string
 	commandList []*Command
+
+	recv       reflect.Value // registered options struct
+	fields     []*flagField
+	globals    []*flagField // global options inherited from parent commands
+	validators []*structValidator
+
+	args        []string      // positional args
+	positionals []*argField   // fields tagged arg
+	passthrough reflect.Value // field of args after --
 }
 
 // NewEFlag is the constructor of EFlag.
@@ -43,13 +64,20 @@ func NewEFlag(commandMode CommandMode, options ...EFlagOption) *EFlag {
 	for _, opt := range options {
 		opt(&config)
 	}
+	name := os.Args[0]
+	if config.ProgramName != "" {
+		name = config.ProgramName
+	}
+	return newEFlag(name, commandMode, &config)
+}
 
+func newEFlag(name string, commandMode CommandMode, config *Config) *EFlag {
 	eFlag := &EFlag{
-		config:      &config,
+		config:      config,
 		commandMode: commandMode,
 	}
 
-	flagSet := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	flagSet := flag.NewFlagSet(name, config.ErrorHandling)
 	flagSet.Usage = eFlag.Usage
 	flagSet.SetOutput(&eFlag.errOutput)
 
@@ -58,81 +86,406 @@ func NewEFlag(commandMode CommandMode, options ...EFlagOption) *EFlag {
 }
 
 // Parse parse command-line options to v.
+//
+// Each option is set from the following sources, a later one overriding the
+// earlier ones: default struct tag, <Field>Default method, config file,
+// environment variable, command-line. Use Source to find out which one won.
 func (e *EFlag) Parse(v interface{}) error {
 	if e.flagSet.Parsed() {
 		return nil
 	}
+	rv, err := e.registerStruct(v)
+	if err != nil {
+		return err
+	}
+	if ok, err := e.completionCommand(os.Args[1:]); ok {
+		return err
+	}
+	if len(os.Args) > 1 && os.Args[1] == COMPLETE_COMMAND_NAME {
+		e.complete(os.Stdout, os.Args[2:])
+		return e.done(ErrCompletion)
+	}
+	return e.parseCommandLine(rv, os.Args[1:])
+}
+
+// registerStruct register the options of struct pointer v, once for Parse
+// and the documentation generators.
+func (e *EFlag) registerStruct(v interface{}) (reflect.Value, error) {
 	if !isStructPtr(v) {
-		return errors.New("Must be a pointer to a struct type")
+		return reflect.Value{}, errors.New("Must be a pointer to a struct type")
+	}
+	rv := reflect.ValueOf(v)
+	if e.recv.IsValid() {
+		return rv, nil
 	}
 
-	ReflectVisitStructField(v, true, e.parse)
-	err := e.flagSet.Parse(e.checkCommandMode(true))
-	if err == nil {
-		e.setArgs(v)
+	if e.config.ConfigFlag != "" {
+		e.flagSet.String(e.config.ConfigFlag, e.config.ConfigFile, "load options from JSON config file")
+	}
+	return rv, e.register(rv)
+}
+
+// register parse the fields of struct pointer rv to flags.
+func (e *EFlag) register(rv reflect.Value) error {
+	e.recv = rv
+	err := e.parseStruct(rv, structScope{recv: rv})
+	if err != nil {
+		return err
+	}
+	if err = e.parseStructValidator(rv, ""); err != nil {
+		return err
 	}
-	return err
+	if err = e.checkPositionals(); err != nil {
+		return err
+	}
+	if err = e.checkOptionCommands(); err != nil {
+		return err
+	}
+	if err = e.inheritGlobals(); err != nil {
+		return err
+	}
+	if err = e.checkCommandMethods(); err != nil {
+		return err
+	}
+	return e.checkRequiredConds()
+}
+
+// parseCommandLine set the options of struct pointer rv from every source,
+// then parse the args left to the selected sub-command, if any.
+func (e *EFlag) parseCommandLine(rv reflect.Value, args []string) (err error) {
+	args, subArgs, err := e.checkCommandMode(args)
+	if err != nil {
+		return err
+	}
+	if e.isHelpCommand() {
+		return e.help(args)
+	}
+	if e.commandName != "" && e.currentCommand() == nil {
+		return e.failf("unknown sub command %q%s", e.commandName, e.suggestCommand(e.commandName))
+	}
+
+	if err = e.loadConfig(args); err != nil {
+		return err
+	}
+	if err = e.loadEnv(); err != nil {
+		return err
+	}
+	if e.config.GNUStyle {
+		if args, err = e.gnuArgs(args); err != nil {
+			return err
+		}
+	}
+	if err = e.checkUnknownFlags(args); err != nil {
+		return err
+	}
+	if err = e.parseArgs(args); err != nil {
+		return e.valueError(err)
+	}
+	if err = e.checkCommandOptions(); err != nil {
+		return err
+	}
+	e.setSources()
+	e.setArgs(rv.Interface())
+	if err = e.setPositionals(); err != nil {
+		return err
+	}
+
+	// global options may follow the sub-command name, check them after
+	if cmd := e.currentCommand(); cmd != nil && cmd.sub != nil {
+		if err = cmd.sub.parseCommandLine(cmd.value, subArgs); err != nil {
+			return err
+		}
+	}
+	if err = e.checkRequired(); err != nil {
+		return err
+	}
+	if err = e.checkRules(); err != nil {
+		return err
+	}
+	return e.checkMethods()
 }
 
 func (e *EFlag) isMode(mode CommandMode) bool {
 	return e.commandMode == mode
 }
 
-func (e *EFlag) checkCommandMode(exitOnError bool) (args []string) {
-	if e.isMode(COMMAND_MODE_OPTION) {
-		return os.Args[1:]
-	} else if e.isMode(COMMAND_MODE_SUB_CMD) && len(os.Args) > SUM_COMMAND_INDEX {
-		if name := os.Args[SUM_COMMAND_INDEX]; name[0] != '-' {
-			e.commandName = name
-			return os.Args[SUM_COMMAND_INDEX+1:]
-		} else if exitOnError {
-			fmt.Fprintf(os.Stderr, "Not valid sub command format\n")
-			os.Exit(1)
+// checkCommandMode take the sub-command name from args in sub-command mode,
+// returning the option args and, for struct sub-commands, the args of the sub-command.
+// Only global options may come before the name of a flat sub-command, eg: app -v show -name x
+// Before the name of a struct sub-command, the options of this level are accepted too,
+// eg: app -v remote add -name x
+func (e *EFlag) checkCommandMode(args []string) (optArgs, subArgs []string, err error) {
+	if e.isMode(COMMAND_MODE_OPTION) || len(e.commandList) == 0 {
+		return args, nil, nil
+	}
+	globals, rest, local, err := e.splitGlobalArgs(args)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(rest) > 0 && rest[0] != "--" {
+		e.commandName = e.resolveCommand(rest[0])
+	}
+
+	cmd := e.currentCommand()
+	if local != "" && (cmd == nil && e.commandName == "" || cmd != nil && cmd.sub == nil) {
+		return nil, nil, e.failf("flag %s must follow the sub command name", e.flagDisplayName(local))
+	}
+	switch {
+	case e.commandName == "":
+		return args, nil, nil
+	case cmd != nil && cmd.sub != nil:
+		return globals, rest[1:], nil
+	}
+	return append(globals, rest[1:]...), nil, nil
+}
+
+// structScope is the context the fields of a struct are parsed in.
+type structScope struct {
+	recv   reflect.Value // receiver of <Field>Default and <Name>Command methods
+	owner  reflect.Type  // struct type declaring the fields
+	prefix string        // flag name prefix, eg: db.
+	path   string        // field path prefix, eg: DB.
+
+	parents []reflect.Type // struct types being parsed, to detect recursive structs
+}
+
+// parseStruct parse the fields of struct pointer rv.
+// Nested structs are parsed recursively, their flags prefixed, eg: db.host
+// Embedded structs are flattened into rv, their methods resolved on the
+// embedding receiver the way Go promotes them.
+// A struct nested in itself is an error, eg: Next *node in node.
+func (e *EFlag) parseStruct(rv reflect.Value, scope structScope) (err error) {
+	scope.owner = rv.Elem().Type()
+	for _, typ := range scope.parents {
+		if typ == scope.owner {
+			return fmt.Errorf("recursive struct %s of field %s", scope.owner, strings.TrimSuffix(scope.path, "."))
 		}
 	}
+	scope.parents = append(append([]reflect.Type{}, scope.parents...), scope.owner)
+	reflectVisitStructValue(rv, false, func(_ reflect.Value, field reflect.StructField, fieldValue reflect.Value) bool {
+		if field.Anonymous {
+			var embedded reflect.Value
+			if embedded, err = e.embeddedStruct(field, fieldValue); err == nil && embedded.IsValid() {
+				err = e.parseStruct(embedded, scope)
+			}
+		} else if nested, ok := e.nestedStruct(field, fieldValue); ok {
+			err = e.parseStruct(nested, structScope{
+				recv:   nested,
+				prefix: scope.prefix + nestedPrefix(field),
+				path:   scope.path + field.Name + ".",
+
+				parents: scope.parents,
+			})
+			if err == nil {
+				err = e.parseStructValidator(nested, scope.path+field.Name)
+			}
+		} else {
+			err = e.parse(scope, field, fieldValue)
+		}
+		return err != nil
+	})
 	return
 }
 
-func (e *EFlag) parse(rv reflect.Value, field reflect.StructField, fieldValue reflect.Value) (ret bool) {
-	e.parseCommand(rv, field, fieldValue)
+// embeddedStruct returns the pointer of anonymous field if it is a struct
+// declaring options, an invalid value otherwise. A nil struct pointer is
+// allocated, unless its type is unexported.
+func (e *EFlag) embeddedStruct(field reflect.StructField, fieldValue reflect.Value) (reflect.Value, error) {
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || !e.declaresOptions(typ, map[reflect.Type]bool{}) {
+		return reflect.Value{}, nil
+	}
+
+	if fieldValue.Kind() == reflect.Struct {
+		return fieldValue.Addr(), nil
+	}
+	if fieldValue.IsNil() {
+		if !fieldValue.CanSet() {
+			return reflect.Value{}, fmt.Errorf("embedded field %s is a nil pointer of unexported type, allocate it before Parse", field.Name)
+		}
+		fieldValue.Set(reflect.New(typ))
+	}
+	return fieldValue, nil
+}
+
+// nestedStruct returns the pointer of field if it is a struct declaring options.
+// A nil struct pointer is allocated.
+func (e *EFlag) nestedStruct(field reflect.StructField, fieldValue reflect.Value) (reflect.Value, bool) {
+	if field.PkgPath != "" || field.Tag.Get(e.config.TagName) != "" {
+		return reflect.Value{}, false
+	}
+	for _, key := range []string{ARG_TAG_KEY, COMMAND_SUB_COMMAND_TAG_KEY} {
+		if _, ok := field.Tag.Lookup(key); ok {
+			return reflect.Value{}, false
+		}
+	}
+
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct || !e.declaresOptions(typ, map[reflect.Type]bool{}) {
+		return reflect.Value{}, false
+	}
+
+	if fieldValue.Kind() == reflect.Struct {
+		return fieldValue.Addr(), true
+	}
+	if fieldValue.IsNil() {
+		fieldValue.Set(reflect.New(typ))
+	}
+	return fieldValue, true
+}
+
+// declaresOptions report whether struct typ has flag or command fields, directly or nested.
+func (e *EFlag) declaresOptions(typ reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[typ] {
+		return false
+	}
+	seen[typ] = true
+
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+		for _, key := range []string{e.config.TagName, ARG_TAG_KEY, COMMAND_FIELD_TAG_KEY, COMMAND_SUB_COMMAND_TAG_KEY} {
+			if _, ok := field.Tag.Lookup(key); ok {
+				return true
+			}
+		}
+		ftyp := field.Type
+		if ftyp.Kind() == reflect.Ptr {
+			ftyp = ftyp.Elem()
+		}
+		if ftyp.Kind() == reflect.Struct && e.declaresOptions(ftyp, seen) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedPrefix returns the flag prefix of nested struct field.
+// The prefix tag overrides the default lowercase field name, an empty prefix tag disables it.
+func nestedPrefix(field reflect.StructField) string {
+	prefix, ok := field.Tag.Lookup(NESTED_PREFIX_TAG_KEY)
+	if !ok {
+		prefix = strings.ToLower(field.Name)
+	}
+	if prefix == "" {
+		return ""
+	}
+	return prefix + "."
+}
+
+func (e *EFlag) parse(scope structScope, field reflect.StructField, fieldValue reflect.Value) error {
+	rv := scope.recv
+	if e.parsePassthrough(field, fieldValue) {
+		return nil
+	}
+	if ok, err := e.parsePositional(scope, field, fieldValue); ok {
+		return err
+	}
+	if err := e.parseCommand(rv, field, fieldValue); err != nil {
+		return err
+	}
 	// parse flag
 	tagName := field.Tag.Get(e.config.TagName)
 	if tagName == "" {
-		return
+		return nil
 	}
+	tagName = scope.prefix + tagName
 
-	val := e.parseDefault(rv, field, fieldValue)
-	usage := field.Tag.Get("usage")
-	e.flagSet.Var(val, tagName, usage)
+	f := newFlagField(tagName, field, fieldValue, e.config)
+	f.fieldName = scope.path + field.Name
+	f.owner = scope.owner
+	if f.short = field.Tag.Get(e.config.TagNameShort); f.short != "" {
+		f.short = scope.prefix + f.short
+	}
+	if err := e.checkRedefined(f); err != nil {
+		return err
+	}
+	if err := e.parseDefault(rv, field, f); err != nil {
+		return err
+	}
+
+	f.usage = field.Tag.Get("usage")
+	f.env = envName(field, tagName, e.config)
+	f.commands = parseOptionCommands(field)
+	if err := parseRequired(field, f); err != nil {
+		return err
+	}
+	if err := parseRules(field, f); err != nil {
+		return err
+	}
+	validate, err := validateMethod(rv, field.Name+VALIDATE_METHOD_NAME_KEY)
+	if err != nil {
+		return err
+	}
+	f.validate = validate
+	f.description = callDescribeMethod(rv, field.Name+DESCRIPTION_METHOD_NAME_KEY)
+	if f.complete, err = completeMethod(rv, field.Name+COMPLETE_METHOD_NAME_KEY); err != nil {
+		return err
+	}
+	f.global = ParseBool(field.Tag.Get(COMMAND_GLOBAL_TAG_KEY), false)
+	e.varFlag(f)
+	e.fields = append(e.fields, f)
+	return nil
+}
+
+// varFlag register the flag and short flag of f to flag set.
+func (e *EFlag) varFlag(f *flagField) {
+	usage := f.usageString()
+	e.flagSet.Var(f.value, f.name, usage)
 
 	// parse short flag
-	tagNameShort := field.Tag.Get(e.config.TagNameShort)
-	if tagNameShort != "" {
-		cval := val
-		e.flagSet.Var(cval, tagNameShort, fmt.Sprintf("%s(same as %s)", usage, tagName))
+	if f.short != "" {
+		e.flagSet.Var(f.value, f.short, fmt.Sprintf("%s(same as %s)", usage, f.name))
 	}
-	return
 }
 
-func (e *EFlag) parseCommand(rv reflect.Value, field reflect.StructField, fieldValue reflect.Value) {
+// checkRedefined returns an error if a flag name of f is already declared,
+// eg: by two embedded structs.
+func (e *EFlag) checkRedefined(f *flagField) error {
+	for _, name := range []string{f.name, f.short} {
+		if name == "" || e.flagSet.Lookup(name) == nil {
+			continue
+		}
+		if other := e.lookupFlag(name); other != nil {
+			return fmt.Errorf("flag -%s of %s redefined, already declared by %s", name, f, other)
+		}
+		return fmt.Errorf("flag -%s of %s redefined", name, f)
+	}
+	return nil
+}
+
+func (e *EFlag) parseCommand(rv reflect.Value, field reflect.StructField, fieldValue reflect.Value) error {
 	if e.isMode(COMMAND_MODE_SUB_CMD) {
 		// parse sub command
 		tagStr := field.Tag.Get(COMMAND_SUB_COMMAND_TAG_KEY)
 		if tagStr == "" {
-			return
+			return nil
 		}
-		e.commandList = append(e.commandList, &Command{
+		cmd := &Command{
 			Name:       tagStr,
+			Aliases:    parseCommandAliases(field),
 			MethodName: field.Name,
 			Mode:       COMMAND_MODE_SUB_CMD,
 			Usage:      field.Tag.Get("usage"),
 			rv:         rv,
-		})
+		}
+		if err := e.parseSubCommand(cmd, field, fieldValue); err != nil {
+			return err
+		}
+		e.commandList = append(e.commandList, cmd)
 	} else if e.isMode(COMMAND_MODE_OPTION) {
 		// parse option command
 		cmdStr, ok := field.Tag.Lookup(COMMAND_FIELD_TAG_KEY)
 		if !ok || !isReflectType(field.Type, reflect.Bool, reflect.String) {
-			return
+			return nil
 		}
 		methodName, runFlag := parseCommand(cmdStr, field.Type.Kind(), field.Name)
 		e.commandList = append(e.commandList, &Command{
@@ -145,65 +498,106 @@ func (e *EFlag) parseCommand(rv reflect.Value, field reflect.StructField, fieldV
 			rv:         rv,
 		})
 	}
+	return nil
 }
 
-func (e *EFlag) parseDefault(rv reflect.Value, field reflect.StructField, fieldValue reflect.Value) flag.Value {
-	defaultValue := field.Tag.Get("default") // from struct tag
-
-	var flagValue flag.Value
-
-	val := NewValue(defaultValue, fieldValue, e.config)
-	flagValue = val
-	if fieldValue.Kind() == reflect.Bool {
-		flagValue = NewBoolValue(*val)
+// parseDefault set the default value from struct tag, then from <Field>Default method.
+func (e *EFlag) parseDefault(rv reflect.Value, field reflect.StructField, f *flagField) error {
+	if defaultValue := field.Tag.Get("default"); defaultValue != "" {
+		if err := f.set(defaultValue, Source{Kind: SOURCE_DEFAULT_TAG}); err != nil {
+			return fmt.Errorf("invalid default tag of %s: %w", f, err)
+		}
 	}
 
-	flagValue.Set(defaultValue)
-	// from default method
-	rm := rv.MethodByName(field.Name + "Default")
+	methodName := field.Name + "Default"
+	rm := rv.MethodByName(methodName)
 	if rm.IsValid() {
 		if results := rm.Call(nil); len(results) > 0 {
-			fieldValue.Set(results[0])
+			f.setValue(results[0], Source{Kind: SOURCE_DEFAULT_METHOD, Name: methodName})
+		}
+	}
+	return nil
+}
+
+// valueError returns the ValueError behind a command-line error, the flag
+// package only keeps its message. Other errors of the flag package are
+// returned as a UsageError.
+func (e *EFlag) valueError(err error) error {
+	for _, fields := range [][]*flagField{e.fields, e.globals} {
+		for _, f := range fields {
+			if f.val.err != nil {
+				return f.val.err
+			}
 		}
 	}
-	return flagValue
+	if err == flag.ErrHelp {
+		return err
+	}
+	return &UsageError{Err: err}
+}
+
+// setSources record command-line options as the source of their fields.
+func (e *EFlag) setSources() {
+	e.flagSet.Visit(func(fl *flag.Flag) {
+		if f := e.lookupFlag(fl.Name); f != nil {
+			f.source = Source{Kind: SOURCE_COMMAND_LINE, Name: e.flagDisplayName(fl.Name)}
+		}
+	})
 }
 
 func (e *EFlag) setArgs(v interface{}) {
-	if e.flagSet.NArg() > 0 {
+	if len(e.args) > 0 {
 		elem := reflect.ValueOf(v).Elem()
 		structField, ok := elem.Type().FieldByName("Args")
 		if !ok || !isStringSlice(structField) {
 			return
 		}
 		// set args
-		elem.FieldByName("Args").Set(reflect.ValueOf(e.flagSet.Args()))
+		elem.FieldByName("Args").Set(reflect.ValueOf(e.args))
 	}
 }
 
-func (e *EFlag) RunCommand() {
-	var currentCommand *Command
+// RunCommand run the command selected by the command-line, returning its error.
+func (e *EFlag) RunCommand() error {
+	return e.RunCommandContext(context.Background())
+}
+
+// RunCommandContext is like RunCommand, passing ctx to the command method.
+func (e *EFlag) RunCommandContext(ctx context.Context) error {
+	currentCommand := e.currentCommand()
+	if currentCommand != nil && currentCommand.sub != nil && currentCommand.shouldRunSub() {
+		return currentCommand.sub.RunCommandContext(ctx)
+	} else if currentCommand != nil {
+		args := e.args
+		if currentCommand.sub != nil {
+			args = currentCommand.sub.args
+		}
+		return currentCommand.Run(ctx, args)
+	} else if e.isMode(COMMAND_MODE_SUB_CMD) {
+		return e.failf("missing sub command")
+	}
+	return nil
+}
+
+// currentCommand returns the command to run, nil if none.
+func (e *EFlag) currentCommand() *Command {
 	for _, cmd := range e.commandList {
 		if cmd.ShouldRun(e.commandName) {
-			currentCommand = cmd
-			break
+			return cmd
 		}
 	}
-	if currentCommand != nil {
-		currentCommand.Run()
-	} else if e.isMode(COMMAND_MODE_SUB_CMD) {
-		fmt.Fprintf(os.Stderr, "Not support sub command\n")
-		e.Usage()
-		os.Exit(1)
-	}
+	return nil
 }
 
 func (e *EFlag) ParseAndRunCommand(v interface{}) error {
-	err := e.Parse(v)
-	if err == nil {
-		e.RunCommand()
+	return e.ParseAndRunCommandContext(context.Background(), v)
+}
+
+func (e *EFlag) ParseAndRunCommandContext(ctx context.Context, v interface{}) error {
+	if err := e.Parse(v); err != nil {
+		return err
 	}
-	return err
+	return e.RunCommandContext(ctx)
 }
 
 func (e *EFlag) Usage() {
@@ -211,13 +605,37 @@ func (e *EFlag) Usage() {
 	if e.errOutput.Len() != 0 {
 		e.errOutput.WriteByte('\n')
 	}
+	cmd := e.currentCommand()
+	if e.isMode(COMMAND_MODE_SUB_CMD) && cmd != nil {
+		binName += " " + cmd.Name
+	}
 	e.errOutput.WriteString(fmt.Sprintf("Usage of %s:\n", binName))
-	if e.isMode(COMMAND_MODE_SUB_CMD) && len(e.commandList) > 0 {
+	if len(e.positionals) > 0 && (!e.isMode(COMMAND_MODE_SUB_CMD) || len(e.commandList) == 0) {
+		e.errOutput.WriteString(fmt.Sprintf("%s [OPTIONS] %s\n", binName, e.positionalUsage()))
+	}
+	if e.isMode(COMMAND_MODE_SUB_CMD) && len(e.commandList) > 0 && cmd == nil {
 		e.errOutput.WriteString(fmt.Sprintf("%s {SUB_COMMAND} {OPTION}\n", binName))
 		e.errOutput.WriteString("SUB_COMMAND is\n")
 		e.errOutput.WriteString(formatCommandUsage(e.commandList))
 		e.errOutput.WriteString("\nOPTION is\n")
 	}
-	e.flagSet.PrintDefaults()
+	if e.config.GNUStyle {
+		e.printGNUDefaults()
+	} else {
+		e.printDefaults()
+	}
 	fmt.Print(e.errOutput.String())
 }
+
+// printDefaults print the options accepted by the current sub-command like flag.PrintDefaults.
+func (e *EFlag) printDefaults() {
+	flagSet := flag.NewFlagSet(e.flagSet.Name(), flag.ContinueOnError)
+	flagSet.SetOutput(&e.errOutput)
+	e.flagSet.VisitAll(func(fl *flag.Flag) {
+		if e.isFlagAllowed(fl.Name) {
+			flagSet.Var(fl.Value, fl.Name, fl.Usage)
+			flagSet.Lookup(fl.Name).DefValue = fl.DefValue
+		}
+	})
+	flagSet.PrintDefaults()
+}
@@ -0,0 +1,313 @@
+package eflag
+
+import (
+	"flag"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setArgs replace os.Args for the duration of a test.
+func setArgs(t *testing.T, args ...string) {
+	old := os.Args
+	os.Args = append([]string{"app"}, args...)
+	t.Cleanup(func() {
+		os.Args = old
+	})
+}
+
+// parseWith parse opt from args in mode, returning command-line errors instead of exiting.
+func parseWith(t *testing.T, mode CommandMode, opt interface{}, args []string, options ...EFlagOption) (*EFlag, error) {
+	setArgs(t, args...)
+	ef := NewEFlag(mode, append(options, WithErrorHandling(flag.ContinueOnError))...)
+	return ef, ef.Parse(opt)
+}
+
+// setEnv set environment variable for the duration of a test.
+func setEnv(t *testing.T, name, value string) {
+	old, ok := os.LookupEnv(name)
+	os.Setenv(name, value)
+	t.Cleanup(func() {
+		if ok {
+			os.Setenv(name, old)
+		} else {
+			os.Unsetenv(name)
+		}
+	})
+}
+
+type envOptions struct {
+	Name     string        `flag:"name" default:"lycb" env:"TEST_APP_NAME" usage:"user name"`
+	Age      int           `flag:"age" default:"23" usage:"user age"`
+	Sleep    time.Duration `flag:"sleep" default:"10ms" usage:"sleep duration"`
+	LogLevel string        `flag:"log-level" default:"info" usage:"log level"`
+	NoEnv    string        `flag:"no-env" env:"-" usage:"never from env"`
+}
+
+func TestEnv(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-age=31")
+	setEnv(t, "TEST_APP_NAME", "lisi")
+	setEnv(t, "TEST_AGE", "40")
+	setEnv(t, "TEST_SLEEP", "1s")
+	setEnv(t, "TEST_LOG_LEVEL", "debug")
+	setEnv(t, "TEST_NO_ENV", "x")
+
+	opt := &envOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION, WithEnvPrefix("TEST_"))
+	assert.Nil(ef.Parse(opt))
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(31, opt.Age) // command-line wins
+	assert.Equal(time.Second, opt.Sleep)
+	assert.Equal("debug", opt.LogLevel)
+	assert.Equal("", opt.NoEnv)
+
+	ef.Usage()
+	usage := ef.errOutput.String()
+	assert.Contains(usage, "[env TEST_APP_NAME]")
+	assert.Contains(usage, "[env TEST_LOG_LEVEL]")
+	assert.Contains(usage, "(default lycb)")
+}
+
+func TestEnvWithoutPrefix(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t)
+	setEnv(t, "TEST_APP_NAME", "lisi")
+	setEnv(t, "AGE", "40")
+
+	opt := &envOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(opt))
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(23, opt.Age)
+}
+
+type sourceOptions struct {
+	Name    string            `flag:"name" flag_short:"n" default:"lycb" usage:"user name"`
+	Age     int               `flag:"age" default:"23" usage:"user age"`
+	Man     bool              `flag:"man" default:"false" usage:"user sex"`
+	City    string            `flag:"city" default:"beijing" usage:"user city"`
+	Salary  float64           `flag:"salary" usage:"user salary"`
+	Headers map[string]string `flag:"header" default:"name=lisi" usage:"request header"`
+}
+
+func (opt *sourceOptions) ManDefault() bool {
+	return true
+}
+
+func (opt *sourceOptions) HeadersDefault() map[string]string {
+	return map[string]string{"lang": "golang"}
+}
+
+func TestSource(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"city": "linzhou", "age": 30}`)
+	setArgs(t, "-n", "lisi")
+	setEnv(t, "TEST_AGE", "40")
+
+	opt := &sourceOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path), WithEnvPrefix("TEST"))
+	assert.Nil(ef.Parse(opt))
+
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "-n"}, ef.Source("Name"))
+	assert.Equal(40, opt.Age)
+	assert.Equal(Source{Kind: SOURCE_ENV, Name: "TEST_AGE"}, ef.Source("age"))
+	assert.Equal("linzhou", opt.City)
+	assert.Equal(Source{Kind: SOURCE_CONFIG_FILE, Name: path}, ef.Source("City"))
+	assert.True(opt.Man)
+	assert.Equal("ManDefault()", ef.Source("Man").String())
+	assert.Equal(map[string]string{"lang": "golang"}, opt.Headers)
+	assert.Equal(SOURCE_DEFAULT_METHOD, ef.Source("Headers").Kind)
+	assert.Equal(SOURCE_NONE, ef.Source("Salary").Kind)
+	assert.Equal(SOURCE_NONE, ef.Source("NotExist").Kind)
+
+	ef.Usage()
+	assert.Contains(ef.errOutput.String(), "(default lang=golang)")
+}
+
+type dbOptions struct {
+	Host    string        `flag:"host" default:"localhost" usage:"database host"`
+	Port    int           `flag:"port" usage:"database port"`
+	Timeout time.Duration `flag:"timeout" usage:"connect timeout"`
+	Ping    bool          `flag:"ping" usage:"ping database" command:""`
+
+	pinged bool
+}
+
+func (opt *dbOptions) PingCommand() {
+	opt.pinged = true
+}
+
+func (opt *dbOptions) PortDefault() int {
+	return 3306
+}
+
+type nestedOptions struct {
+	Name    string     `flag:"name" usage:"user name"`
+	DB      dbOptions  // -db.host
+	Replica *dbOptions `prefix:"slave"` // -slave.host
+	Cache   struct {
+		Size int `flag:"size" default:"64" usage:"cache size"`
+	} `prefix:""` // -size
+	Skip   *time.Location // no options, left nil
+	Reload bool           `flag:"reload" command:""`
+}
+
+func TestNestedStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"db": {"timeout": "1s"}, "slave.port": 3307}`)
+	setArgs(t, "-db.host=10.0.0.1", "-slave.host", "10.0.0.2", "-size=128")
+	setEnv(t, "TEST_SLAVE_TIMEOUT", "2s")
+
+	opt := &nestedOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path), WithEnvPrefix("TEST"))
+	assert.Nil(ef.Parse(opt))
+
+	assert.Equal("10.0.0.1", opt.DB.Host)
+	assert.Equal(3306, opt.DB.Port)
+	assert.Equal(time.Second, opt.DB.Timeout)
+	if assert.NotNil(opt.Replica) {
+		assert.Equal("10.0.0.2", opt.Replica.Host)
+		assert.Equal(3307, opt.Replica.Port)
+		assert.Equal(2*time.Second, opt.Replica.Timeout)
+	}
+	assert.Equal(128, opt.Cache.Size)
+	assert.Nil(opt.Skip)
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "-db.host"}, ef.Source("DB.Host"))
+	assert.Equal(SOURCE_CONFIG_FILE, ef.Source("slave.port").Kind)
+	assert.Equal(SOURCE_DEFAULT_METHOD, ef.Source("db.port").Kind)
+}
+
+type recursiveNode struct {
+	Name string `flag:"name"`
+	Next *recursiveNode
+}
+
+func TestNestedRecursive(t *testing.T) {
+	setArgs(t)
+
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&struct {
+		Head recursiveNode
+	}{})
+	assert.EqualError(t, err, "recursive struct eflag.recursiveNode of field Head.Next")
+
+	err = NewEFlag(COMMAND_MODE_OPTION).Parse(&recursiveNode{})
+	assert.EqualError(t, err, "recursive struct eflag.recursiveNode of field Next")
+}
+
+func TestNestedCommand(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-db.ping")
+
+	opt := &nestedOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).ParseAndRunCommand(opt))
+	assert.True(opt.DB.pinged)
+	assert.False(opt.Replica.pinged)
+}
+
+type CommonOptions struct {
+	Verbose bool   `flag:"verbose" flag_short:"v" usage:"verbose output"`
+	Level   string `flag:"level" usage:"log level"`
+	Version bool   `flag:"version" usage:"print version" command:""`
+
+	version string
+}
+
+func (opt *CommonOptions) LevelDefault() string {
+	return "info"
+}
+
+func (opt *CommonOptions) VersionCommand() {
+	opt.version = "v1.0.0"
+}
+
+type TraceOptions struct {
+	Trace bool `flag:"trace" usage:"enable trace"`
+}
+
+type embeddedOptions struct {
+	CommonOptions
+	*TraceOptions
+	Name string `flag:"name" usage:"user name"`
+}
+
+func (opt *embeddedOptions) NameDefault() string {
+	return "lycb"
+}
+
+func TestEmbeddedStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-v", "-trace", "-version")
+
+	opt := &embeddedOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION)
+	assert.Nil(ef.ParseAndRunCommand(opt))
+	assert.True(opt.Verbose)
+	assert.Equal("info", opt.Level)
+	assert.Equal("lycb", opt.Name)
+	assert.True(opt.Trace)
+	assert.Equal("v1.0.0", opt.version)
+	assert.Equal(SOURCE_COMMAND_LINE, ef.Source("Verbose").Kind)
+}
+
+type commonOptions struct {
+	Verbose bool   `flag:"verbose" flag_short:"v"`
+	Level   string `flag:"level"`
+}
+
+func (opt *commonOptions) LevelDefault() string {
+	return "info"
+}
+
+type unexportedEmbeddedOptions struct {
+	commonOptions
+	Name string `flag:"name"`
+}
+
+type unexportedEmbeddedPtrOptions struct {
+	*commonOptions
+}
+
+func TestEmbeddedUnexportedStruct(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-v", "-name", "x")
+
+	opt := &unexportedEmbeddedOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(opt))
+	assert.True(opt.Verbose)
+	assert.Equal("info", opt.Level)
+	assert.Equal("x", opt.Name)
+
+	setArgs(t, "-v")
+	ptrOpt := &unexportedEmbeddedPtrOptions{commonOptions: &commonOptions{}}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(ptrOpt))
+	assert.True(ptrOpt.Verbose)
+
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&unexportedEmbeddedPtrOptions{})
+	assert.EqualError(err, "embedded field commonOptions is a nil pointer of unexported type, allocate it before Parse")
+}
+
+type LoggerOptions struct {
+	Verbose bool `flag:"verbose" usage:"verbose log"`
+}
+
+type redefinedOptions struct {
+	CommonOptions
+	LoggerOptions
+}
+
+func TestEmbeddedRedefined(t *testing.T) {
+	setArgs(t)
+
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&redefinedOptions{})
+	assert.EqualError(t, err, "flag -verbose of LoggerOptions.Verbose redefined, already declared by CommonOptions.Verbose")
+}
@@ -0,0 +1,61 @@
+package eflag
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+const (
+	ENV_TAG_KEY = "env"
+)
+
+// envName returns the environment variable bound to field.
+// An explicit env tag wins, "-" disables the binding, otherwise the name is
+// derived from the flag name when an env prefix is configured.
+func envName(field reflect.StructField, flagName string, c *Config) string {
+	if name, ok := field.Tag.Lookup(ENV_TAG_KEY); ok {
+		if name == "-" {
+			return ""
+		}
+		return name
+	}
+	if c.EnvPrefix == "" {
+		return ""
+	}
+	return c.EnvPrefix + "_" + envNormalize(flagName)
+}
+
+// envNormalize convert flag name to environment variable style, eg: log-level => LOG_LEVEL
+func envNormalize(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// lookupEnv returns the value of the environment variable name.
+func lookupEnv(name string) (string, bool) {
+	if name == "" {
+		return "", false
+	}
+	return os.LookupEnv(name)
+}
+
+// loadEnv set options from environment variables.
+func (e *EFlag) loadEnv() error {
+	for _, f := range e.fields {
+		if sval, ok := lookupEnv(f.env); ok {
+			if err := f.set(sval, Source{Kind: SOURCE_ENV, Name: f.env}); err != nil {
+				return fmt.Errorf("env %s: %w", f.env, err)
+			}
+		}
+	}
+	return nil
+}
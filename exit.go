@@ -0,0 +1,87 @@
+package eflag
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// ExitCoder is implemented by errors carrying the process exit code.
+type ExitCoder interface {
+	error
+	ExitCode() int
+}
+
+// ExitError is an error with the process exit code.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// NewExitError returns an ExitError of err with code.
+func NewExitError(code int, err error) *ExitError {
+	return &ExitError{Code: code, Err: err}
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ExitError) ExitCode() int {
+	return e.Code
+}
+
+// UsageError is returned by Parse for an invalid command-line, eg: an
+// undefined flag, a flag missing its argument or an unknown sub-command.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code of err:
+// 0 for nil, flag.ErrHelp and ErrCompletion, the code of the first ExitCoder in the chain of err,
+// 2 for invalid command-line options, like the flag package, and 1 otherwise.
+func ExitCode(err error) int {
+	var coder ExitCoder
+	var usageErr *UsageError
+	var valueErr *ValueError
+	var requiredErr *RequiredError
+	var validationErr *ValidationError
+	var validationErrs ValidationErrors
+	switch {
+	case err == nil || errors.Is(err, flag.ErrHelp) || errors.Is(err, ErrCompletion):
+		return 0
+	case errors.As(err, &coder):
+		return coder.ExitCode()
+	case errors.As(err, &usageErr), errors.As(err, &valueErr), errors.As(err, &requiredErr),
+		errors.As(err, &validationErr), errors.As(err, &validationErrs):
+		return 2
+	}
+	return 1
+}
+
+// Exit print err to stderr, then exit with the exit code of err, eg:
+//
+//	eflag.Exit(eflag.ParseAndRunCommand(opt))
+func Exit(err error) {
+	code := ExitCode(err)
+	if code != 0 {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	os.Exit(code)
+}
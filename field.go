@@ -0,0 +1,136 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	ITEM_SEP_TAG_KEY = "sep"
+	MAP_SEP_TAG_KEY  = "map_sep"
+)
+
+// flagField is the metadata of a struct field bound to a flag.
+type flagField struct {
+	name      string
+	short     string
+	env       string
+	usage     string
+	fieldName string
+	owner     reflect.Type // struct type declaring the field
+	typ       reflect.Type
+	value     flag.Value // registered to flag set
+	val       *Value     // underlying Value of value
+	source    Source
+
+	required    bool
+	requiredIf  []requiredCond
+	rules       []*validateRule
+	choices     []string      // from oneof tag
+	validate    reflect.Value // <Field>Validate method
+	complete    reflect.Value // <Field>Complete method
+	description string        // from <Field>Description method
+	commands    []string      // sub-commands accepting the option, any if empty
+	global      bool          // accepted before the sub-command name and by sub-commands
+}
+
+func newFlagField(name string, field reflect.StructField, fieldValue reflect.Value, c *Config) *flagField {
+	val := NewValue("", fieldValue, c)
+	val.name = name
+	if sep := field.Tag.Get(ITEM_SEP_TAG_KEY); sep != "" {
+		val.itemSep = sep
+	}
+	if sep := field.Tag.Get(MAP_SEP_TAG_KEY); sep != "" {
+		val.mapSep = sep
+	}
+	f := &flagField{
+		name:      name,
+		fieldName: field.Name,
+		typ:       field.Type,
+		value:     val,
+		val:       val,
+	}
+	if isBoolFlag(field.Type) {
+		bval := NewBoolValue(*val)
+		f.value, f.val = bval, &bval.Value
+	}
+	return f
+}
+
+// String returns the qualified field name, eg: CommonOptions.Verbose
+func (f *flagField) String() string {
+	if f.owner == nil || f.owner.Name() == "" {
+		return f.fieldName
+	}
+	return f.owner.Name() + "." + f.fieldName[strings.LastIndex(f.fieldName, ".")+1:]
+}
+
+// isBoolFlag report whether typ is bool, or a custom type whose IsBoolFlag returns true.
+func isBoolFlag(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Bool || typ.Kind() == reflect.Ptr && typ.Elem().Kind() == reflect.Bool {
+		return true
+	}
+	if bf, ok := reflect.New(typ).Interface().(interface{ IsBoolFlag() bool }); ok {
+		return bf.IsBoolFlag()
+	}
+	return false
+}
+
+// usageString returns the usage decorated with env and requirement.
+func (f *flagField) usageString() string {
+	usage := f.usage
+	if len(f.choices) > 0 {
+		usage = fmt.Sprintf("%s (one of %s)", usage, strings.Join(f.choices, ONEOF_SEP))
+	}
+	if f.env != "" {
+		usage = fmt.Sprintf("%s [env %s]", usage, f.env)
+	}
+	if f.required {
+		usage += " (required)"
+	} else if len(f.requiredIf) > 0 {
+		usage = fmt.Sprintf("%s (required if %s)", usage, formatRequiredConds(f.requiredIf))
+	}
+	return usage
+}
+
+// set parse sval to field and record its source.
+// Unlike command-line flags, the new value always replaces the current one.
+func (f *flagField) set(sval string, src Source) error {
+	if err := f.val.replace(sval); err != nil {
+		return err
+	}
+	f.source = src
+	return nil
+}
+
+// setValue set rv to field and record its source.
+func (f *flagField) setValue(rv reflect.Value, src Source) {
+	f.val.setValue(rv)
+	f.source = src
+}
+
+// lookupField find field by flag name, including inherited global options.
+func (e *EFlag) lookupField(name string) *flagField {
+	for _, fields := range [][]*flagField{e.fields, e.globals} {
+		for _, f := range fields {
+			if f.name == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
+
+// lookupFlag find field by flag name or short flag name, including inherited global options.
+func (e *EFlag) lookupFlag(name string) *flagField {
+	for _, fields := range [][]*flagField{e.fields, e.globals} {
+		for _, f := range fields {
+			if f.name == name || f.short == name {
+				return f
+			}
+		}
+	}
+	return nil
+}
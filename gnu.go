@@ -0,0 +1,176 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// gnuArgs rewrite GNU style args to the form of the flag package.
+//
+// flag names are long options: --name, --name=x, --name x
+// flag_short names are short options: -n x, -nx, and bool ones cluster: -vxf, -vxn x
+// Args after --, or after the first positional arg unless interspersed, are kept as is.
+func (e *EFlag) gnuArgs(args []string) ([]string, error) {
+	out := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		isPositional := len(arg) < 2 || arg[0] != '-'
+		if arg == "--" || isPositional && !e.config.Interspersed {
+			return append(out, args[i:]...), nil
+		}
+		if isPositional {
+			out = append(out, arg)
+			continue
+		}
+
+		var err error
+		if strings.HasPrefix(arg, "--") {
+			out, i, err = e.gnuLong(out, args, i)
+		} else {
+			out, i, err = e.gnuShort(out, args, i)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// gnuLong rewrite the long option args[i], returning the index of its last arg.
+func (e *EFlag) gnuLong(out, args []string, i int) ([]string, int, error) {
+	name, value, hasValue := splitOption(args[i][2:])
+	fl := e.flagSet.Lookup(name)
+	if fl == nil || e.isShortOnly(name) {
+		if name == "help" {
+			return append(out, "-help"), i, nil
+		}
+		return nil, i, e.failf("flag provided but not defined: --%s%s", name, e.suggestFlag(name))
+	}
+	return e.gnuValue(out, args, i, fl, value, hasValue)
+}
+
+// gnuShort rewrite the short options of args[i], returning the index of its last arg.
+func (e *EFlag) gnuShort(out, args []string, i int) ([]string, int, error) {
+	cluster := args[i][1:]
+	// short names longer than one character can not be clustered, eg: -db.h
+	if name, value, hasValue := splitOption(cluster); len(name) > 1 && e.isShort(name) {
+		return e.gnuValue(out, args, i, e.flagSet.Lookup(name), value, hasValue)
+	}
+
+	for j := 0; j < len(cluster); j++ {
+		name := cluster[j : j+1]
+		fl := e.flagSet.Lookup(name)
+		if fl == nil || !e.isShort(name) {
+			if name == "h" {
+				out = append(out, "-h")
+				continue
+			}
+			return nil, i, e.failf("flag provided but not defined: -%s", name)
+		}
+		if isBoolValue(fl.Value) {
+			out = append(out, "-"+name)
+			continue
+		}
+
+		// the rest of cluster is the value: -ofile, -o=file
+		value := cluster[j+1:]
+		return e.gnuValue(out, args, i, fl, strings.TrimPrefix(value, "="), value != "")
+	}
+	return out, i, nil
+}
+
+// gnuValue rewrite option fl, taking its value from the next arg if needed,
+// returning the index of its last arg.
+func (e *EFlag) gnuValue(out, args []string, i int, fl *flag.Flag, value string, hasValue bool) ([]string, int, error) {
+	if hasValue {
+		return append(out, "-"+fl.Name+"="+value), i, nil
+	}
+	if isBoolValue(fl.Value) {
+		return append(out, "-"+fl.Name), i, nil
+	}
+	if i+1 >= len(args) {
+		return nil, i, e.failf("flag needs an argument: %s", e.flagDisplayName(fl.Name))
+	}
+	return append(out, "-"+fl.Name+"="+args[i+1]), i + 1, nil
+}
+
+// splitOption split name=value.
+func splitOption(s string) (name, value string, hasValue bool) {
+	if j := strings.Index(s, "="); j >= 0 {
+		return s[:j], s[j+1:], true
+	}
+	return s, "", false
+}
+
+// isShort report whether name is a flag_short name.
+func (e *EFlag) isShort(name string) bool {
+	f := e.lookupFlag(name)
+	return f != nil && f.short == name
+}
+
+// isShortOnly report whether name is a flag_short name, but not a flag name.
+func (e *EFlag) isShortOnly(name string) bool {
+	return e.isShort(name) && e.lookupField(name) == nil
+}
+
+func isBoolValue(v flag.Value) bool {
+	bf, ok := v.(interface{ IsBoolFlag() bool })
+	return ok && bf.IsBoolFlag()
+}
+
+// flagDisplayName returns name with dashes as given on command-line, eg: --name, -n
+func (e *EFlag) flagDisplayName(name string) string {
+	if e.config.GNUStyle && !e.isShort(name) {
+		return "--" + name
+	}
+	return "-" + name
+}
+
+// failf report a command-line error like the flag package does, printing
+// the message and usage, then exiting or panicking as configured.
+// The error returned is a UsageError.
+func (e *EFlag) failf(format string, a ...interface{}) error {
+	err := &UsageError{Err: fmt.Errorf(format, a...)}
+	fmt.Fprintln(&e.errOutput, err)
+	e.flagSet.Usage()
+
+	switch e.config.ErrorHandling {
+	case flag.ExitOnError:
+		os.Exit(2)
+	case flag.PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// printGNUDefaults print options like flag.PrintDefaults, but GNU style:
+// short and long names on the same line, eg: -n, --name value
+func (e *EFlag) printGNUDefaults() {
+	e.flagSet.VisitAll(func(fl *flag.Flag) {
+		if e.isShortOnly(fl.Name) || !e.isFlagAllowed(fl.Name) {
+			return // printed with its long name, or not an option of the sub-command
+		}
+
+		var b strings.Builder
+		b.WriteString("  ")
+		f := e.lookupField(fl.Name)
+		if f != nil && f.short != "" {
+			fmt.Fprintf(&b, "-%s, ", f.short)
+		}
+		fmt.Fprintf(&b, "--%s", fl.Name)
+
+		valueName, usage := flag.UnquoteUsage(fl)
+		isBool := isBoolValue(fl.Value)
+		if !isBool && valueName != "" {
+			b.WriteString(" " + valueName)
+		}
+		b.WriteString("\n    \t")
+		b.WriteString(strings.ReplaceAll(usage, "\n", "\n    \t"))
+		if fl.DefValue != "" && !(isBool && fl.DefValue == "false") {
+			fmt.Fprintf(&b, " (default %s)", fl.DefValue)
+		}
+		fmt.Fprintln(&e.errOutput, b.String())
+	})
+}
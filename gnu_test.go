@@ -0,0 +1,76 @@
+package eflag
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type gnuOptions struct {
+	Verbose bool     `flag:"verbose" flag_short:"v" usage:"verbose output"`
+	Extract bool     `flag:"extract" flag_short:"x" usage:"extract files"`
+	Force   bool     `flag:"force" flag_short:"f" usage:"force"`
+	Output  string   `flag:"output" flag_short:"o" usage:"output file"`
+	Name    string   `flag:"name" usage:"user name"`
+	Level   int      `flag:"level" flag_short:"l" default:"1" usage:"level"`
+	Addrs   []string `flag:"addr" flag_short:"a" usage:"home address"`
+
+	Args []string
+}
+
+func TestGNUStyle(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &gnuOptions{}
+	ef, err := parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-vxf", "--name", "lisi", "-ofile.txt", "-l", "3", "-a", "-x", "--addr=b", "file1", "-v"}, WithGNUStyle())
+	assert.Nil(err)
+	assert.True(opt.Verbose)
+	assert.True(opt.Extract)
+	assert.True(opt.Force)
+	assert.Equal("file.txt", opt.Output)
+	assert.Equal("lisi", opt.Name)
+	assert.Equal(3, opt.Level)
+	assert.Equal([]string{"-x", "b"}, opt.Addrs)
+	assert.Equal([]string{"file1", "-v"}, opt.Args)
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "--name"}, ef.Source("Name"))
+	assert.Equal(Source{Kind: SOURCE_COMMAND_LINE, Name: "-o"}, ef.Source("Output"))
+
+	opt = &gnuOptions{}
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-vo", "out", "--level=2", "--verbose=false", "--", "-f"}, WithGNUStyle())
+	assert.Nil(err)
+	assert.False(opt.Verbose)
+	assert.Equal("out", opt.Output)
+	assert.Equal(2, opt.Level)
+	assert.False(opt.Force)
+	assert.Equal([]string{"-f"}, opt.Args)
+}
+
+func TestGNUStyleError(t *testing.T) {
+	assert := assert.New(t)
+
+	_, err := parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, []string{"--o=x"}, WithGNUStyle())
+	assert.EqualError(err, "flag provided but not defined: --o")
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, []string{"-name=x"}, WithGNUStyle())
+	assert.EqualError(err, "flag provided but not defined: -n")
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, []string{"-vo"}, WithGNUStyle())
+	assert.EqualError(err, "flag needs an argument: -o")
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, []string{"--name"}, WithGNUStyle())
+	assert.EqualError(err, "flag needs an argument: --name")
+	_, err = parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, []string{"--help"}, WithGNUStyle())
+	assert.Equal(flag.ErrHelp, err)
+}
+
+func TestGNUUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	ef, err := parseWith(t, COMMAND_MODE_OPTION, &gnuOptions{}, nil, WithGNUStyle())
+	assert.Nil(err)
+	ef.Usage()
+	usage := ef.errOutput.String()
+	assert.Contains(usage, "  -o, --output value\n    \toutput file\n")
+	assert.Contains(usage, "  -v, --verbose\n")
+	assert.Contains(usage, "  --name value\n")
+	assert.Contains(usage, "(default 1)")
+	assert.NotContains(usage, "same as")
+}
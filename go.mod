@@ -2,4 +2,4 @@ module github.com/luoyecb/eflag
 
 go 1.15
 
-require github.com/stretchr/testify v1.11.1 // indirect
+require github.com/stretchr/testify v1.11.1
@@ -0,0 +1,174 @@
+package eflag
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// PrintManPage write a roff man page of the options struct v to w, with
+// NAME, SYNOPSIS, DESCRIPTION, OPTIONS, COMMANDS and ENVIRONMENT sections.
+// Long descriptions come from the Description method of v and of struct
+// sub-commands, <Name>Description methods of sub-commands and
+// <Field>Description methods of options, all func() string.
+// The first line of the description of v is the summary of NAME section.
+func (e *EFlag) PrintManPage(w io.Writer, v interface{}) error {
+	if _, err := e.registerStruct(v); err != nil {
+		return err
+	}
+	name := filepath.Base(e.flagSet.Name())
+	doc := e.docCommand(name, "")
+	doc.description = e.description()
+	summary, description := splitDescription(doc.description)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %s 1\n", roffEscape(strings.ToUpper(name)))
+	b.WriteString(".SH NAME\n")
+	b.WriteString(roffEscape(name))
+	if summary != "" {
+		b.WriteString(" \\- " + roffEscape(summary))
+	}
+	b.WriteString("\n.SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n%s\n", roffEscape(name), roffEscape(doc.synopsis))
+	if description != "" {
+		b.WriteString(".SH DESCRIPTION\n")
+		writeRoffText(&b, description)
+	}
+	if len(doc.args) > 0 {
+		b.WriteString(".SH ARGUMENTS\n")
+		writeRoffOptions(&b, doc.args)
+	}
+	if len(doc.options) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		writeRoffOptions(&b, doc.options)
+	}
+	if len(doc.commands) > 0 {
+		b.WriteString(".SH COMMANDS\n")
+		for _, sub := range doc.commands {
+			sub.walk(func(cmd *docCommand) { writeRoffCommand(&b, cmd) })
+		}
+	}
+
+	var envs []*docOption
+	seen := map[string]bool{}
+	doc.walk(func(cmd *docCommand) {
+		for _, opt := range cmd.options {
+			if opt.env != "" && !seen[opt.env] {
+				seen[opt.env] = true
+				envs = append(envs, opt)
+			}
+		}
+	})
+	if len(envs) > 0 {
+		b.WriteString(".SH ENVIRONMENT\n")
+		for _, opt := range envs {
+			fmt.Fprintf(&b, ".TP\n.B %s\n", roffEscape(opt.env))
+			fmt.Fprintf(&b, "Same as %s.\n", roffOptionNames(opt))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeRoffCommand write a sub-command entry of COMMANDS section, with its options indented.
+func writeRoffCommand(b *strings.Builder, cmd *docCommand) {
+	fmt.Fprintf(b, ".TP\n\\fB%s\\fR %s\n", roffEscape(cmd.path), roffEscape(cmd.synopsis))
+	usage := cmd.usage
+	if len(cmd.aliases) > 0 {
+		usage = strings.TrimSpace(fmt.Sprintf("%s (aliases: %s)", usage, strings.Join(cmd.aliases, ", ")))
+	}
+	if usage != "" {
+		b.WriteString(roffEscape(usage) + "\n")
+	}
+	if cmd.description != "" {
+		b.WriteString(".IP\n")
+		writeRoffText(b, cmd.description)
+	}
+	if len(cmd.args) == 0 && len(cmd.options) == 0 {
+		return
+	}
+	b.WriteString(".RS\n")
+	writeRoffOptions(b, cmd.args)
+	writeRoffOptions(b, cmd.options)
+	b.WriteString(".RE\n")
+}
+
+// writeRoffOptions write tagged paragraphs of options or positional args, eg:
+//
+//	.TP
+//	\fB\-n\fR, \fB\-\-name\fR \fIstring\fR
+func writeRoffOptions(b *strings.Builder, opts []*docOption) {
+	for _, opt := range opts {
+		b.WriteString(".TP\n" + roffOptionNames(opt))
+		if !opt.isBool && strings.HasPrefix(opt.names[0], "-") {
+			fmt.Fprintf(b, " \\fI%s\\fR", roffEscape(opt.typ))
+		}
+		b.WriteString("\n")
+
+		var lines []string
+		if opt.usage != "" {
+			lines = append(lines, opt.usage)
+		}
+		if opt.def != "" {
+			lines = append(lines, "Default: "+opt.def+".")
+		}
+		if len(opt.choices) > 0 {
+			lines = append(lines, "One of: "+strings.Join(opt.choices, ", ")+".")
+		}
+		if opt.env != "" {
+			lines = append(lines, "Environment: "+opt.env+".")
+		}
+		if opt.required {
+			lines = append(lines, "Required.")
+		}
+		for i, line := range lines {
+			if i > 0 {
+				b.WriteString(".br\n")
+			}
+			b.WriteString(roffEscape(line) + "\n")
+		}
+		if opt.description != "" {
+			b.WriteString(".IP\n")
+			writeRoffText(b, opt.description)
+		}
+	}
+}
+
+// roffOptionNames returns the bold names of opt, eg: \fB\-n\fR, \fB\-\-name\fR
+// Names of positional args are italic, eg: \fISRC\fR
+func roffOptionNames(opt *docOption) string {
+	names := make([]string, 0, len(opt.names))
+	for _, name := range opt.names {
+		font := `\fB`
+		if !strings.HasPrefix(name, "-") {
+			font = `\fI`
+		}
+		names = append(names, font+roffEscape(name)+`\fR`)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writeRoffText write paragraphs of text, separated by blank lines.
+func writeRoffText(b *strings.Builder, text string) {
+	for i, para := range strings.Split(strings.TrimSpace(text), "\n\n") {
+		if i > 0 {
+			b.WriteString(".PP\n")
+		}
+		b.WriteString(roffEscape(strings.TrimSpace(para)) + "\n")
+	}
+}
+
+// roffEscape escape backslashes and dashes of s, and control characters
+// at the beginning of its lines.
+func roffEscape(s string) string {
+	s = strings.NewReplacer(`\`, `\e`, "-", `\-`).Replace(s)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
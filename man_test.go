@@ -0,0 +1,76 @@
+package eflag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type manOptions struct {
+	Cluster string `flag:"cluster" flag_short:"c" usage:"cluster name" env:"APP_CLUSTER" global:"true"`
+	Level   string `flag:"level" usage:"log level" oneof:"debug|info" default:"info"`
+	Verbose bool   `flag:"v" usage:"verbose output"`
+	Format  string `flag:"format" usage:"output format" commands:"list"`
+
+	Deploy deployManCommand `sub_command:"deploy" usage:"deploy files" aliases:"d"`
+	List   bool             `sub_command:"list" usage:"list releases"`
+}
+
+type deployManCommand struct {
+	Force  bool     `flag:"force" usage:"skip checks"`
+	Target string   `arg:"0" required:"true"`
+	Files  []string `arg:"rest"`
+}
+
+func (opt *manOptions) Description() string {
+	return "deploy releases\n\nApp deploys files to clusters.\n.dot line"
+}
+
+func (opt *manOptions) ClusterDescription() string {
+	return "The cluster is one of prod-eu, staging."
+}
+
+func (opt *manOptions) ListDescription() string {
+	return "List releases of the cluster."
+}
+
+func (opt *deployManCommand) Description() string {
+	return "Deploy files to the target."
+}
+
+func printManPage(t *testing.T, options ...EFlagOption) string {
+	setArgs(t)
+	var b strings.Builder
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD, options...)
+	assert.Nil(t, ef.PrintManPage(&b, &manOptions{}))
+	return b.String()
+}
+
+func TestManPage(t *testing.T) {
+	assert := assert.New(t)
+
+	page := printManPage(t)
+	assert.True(strings.HasPrefix(page, ".TH APP 1\n.SH NAME\napp \\- deploy releases\n"))
+	assert.Contains(page, ".SH SYNOPSIS\n.B app\n[OPTIONS] COMMAND\n")
+	assert.Contains(page, ".SH DESCRIPTION\nApp deploys files to clusters.\n\\&.dot line\n")
+	assert.Contains(page, ".SH OPTIONS\n.TP\n\\fB\\-c\\fR, \\fB\\-cluster\\fR \\fIstring\\fR\ncluster name\n.br\nEnvironment: APP_CLUSTER.\n.IP\nThe cluster is one of prod\\-eu, staging.\n")
+	assert.Contains(page, ".TP\n\\fB\\-level\\fR \\fIstring\\fR\nlog level\n.br\nDefault: info.\n.br\nOne of: debug, info.\n")
+	assert.Contains(page, ".TP\n\\fB\\-v\\fR\nverbose output\n")
+	assert.NotContains(page, ".SH OPTIONS\n.TP\n\\fB\\-format")
+
+	assert.Contains(page, ".SH COMMANDS\n.TP\n\\fBapp deploy\\fR [OPTIONS] TARGET FILES...\ndeploy files (aliases: d)\n.IP\nDeploy files to the target.\n.RS\n")
+	assert.Contains(page, ".TP\n\\fITARGET\\fR\nRequired.\n.TP\n\\fIFILES\\fR\n")
+	assert.Contains(page, ".TP\n\\fB\\-force\\fR\nskip checks\n.RE\n")
+	assert.Contains(page, ".TP\n\\fBapp list\\fR [OPTIONS]\nlist releases\n.IP\nList releases of the cluster.\n.RS\n.TP\n\\fB\\-format\\fR \\fIstring\\fR\noutput format\n.RE\n")
+	assert.True(strings.HasSuffix(page, ".SH ENVIRONMENT\n.TP\n.B APP_CLUSTER\nSame as \\fB\\-c\\fR, \\fB\\-cluster\\fR.\n"))
+
+	page = printManPage(t, WithGNUStyle())
+	assert.Contains(page, "\\fB\\-c\\fR, \\fB\\-\\-cluster\\fR \\fIstring\\fR")
+}
+
+func TestRoffEscape(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`\e\-x`, roffEscape(`\-x`))
+	assert.Equal("a\n\\&.b\n\\&'c", roffEscape("a\n.b\n'c"))
+}
@@ -0,0 +1,129 @@
+package eflag
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// PrintMarkdown write the command-line reference of the options struct v to
+// w as Markdown: the usage, positional args, option tables with type,
+// default, env var and choices, and examples of the program, then one
+// section per sub-command. Descriptions come from the same methods as
+// PrintManPage, examples from Examples methods of v and of struct
+// sub-commands, and <Name>Examples methods of sub-commands, all func() []string.
+//
+// Write it from a program run by go generate, eg:
+//
+//	//go:generate go run ./internal/gendoc -o CLI.md
+func (e *EFlag) PrintMarkdown(w io.Writer, v interface{}) error {
+	if _, err := e.registerStruct(v); err != nil {
+		return err
+	}
+	name := filepath.Base(e.flagSet.Name())
+	doc := e.docCommand(name, "")
+	doc.description, doc.examples = e.description(), e.examples()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", name)
+	writeMarkdownCommand(&b, doc, "##")
+	if len(doc.commands) > 0 {
+		b.WriteString("## Commands\n\n| Command | Description |\n| --- | --- |\n")
+		doc.walk(func(cmd *docCommand) {
+			if cmd != doc {
+				fmt.Fprintf(&b, "| [`%s`](#%s) | %s |\n", cmd.path, markdownAnchor(cmd.path), markdownCell(cmd.usage))
+			}
+		})
+		b.WriteString("\n")
+	}
+	for _, sub := range doc.commands {
+		sub.walk(func(cmd *docCommand) {
+			fmt.Fprintf(&b, "## %s\n\n", cmd.path)
+			if cmd.usage != "" {
+				b.WriteString(cmd.usage + "\n\n")
+			}
+			if len(cmd.aliases) > 0 {
+				fmt.Fprintf(&b, "Aliases: `%s`\n\n", strings.Join(cmd.aliases, "`, `"))
+			}
+			writeMarkdownCommand(&b, cmd, "###")
+		})
+	}
+
+	_, err := io.WriteString(w, strings.TrimSuffix(b.String(), "\n"))
+	return err
+}
+
+// writeMarkdownCommand write the description, usage, args, options and
+// examples of cmd, with headings of level.
+func writeMarkdownCommand(b *strings.Builder, cmd *docCommand, level string) {
+	if cmd.description != "" {
+		b.WriteString(strings.TrimSpace(cmd.description) + "\n\n")
+	}
+	fmt.Fprintf(b, "```\n%s %s\n```\n\n", cmd.path, cmd.synopsis)
+	if len(cmd.args) > 0 {
+		fmt.Fprintf(b, "%s Arguments\n\n", level)
+		writeMarkdownOptions(b, "Argument", cmd.args)
+	}
+	if len(cmd.options) > 0 {
+		fmt.Fprintf(b, "%s Options\n\n", level)
+		writeMarkdownOptions(b, "Option", cmd.options)
+	}
+	if len(cmd.examples) > 0 {
+		fmt.Fprintf(b, "%s Examples\n\n```sh\n%s\n```\n\n", level, strings.Join(cmd.examples, "\n"))
+	}
+}
+
+// writeMarkdownOptions write a table of options or positional args.
+func writeMarkdownOptions(b *strings.Builder, header string, opts []*docOption) {
+	fmt.Fprintf(b, "| %s | Type | Default | Env | Choices | Description |\n", header)
+	b.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, opt := range opts {
+		desc := opt.usage
+		if opt.required {
+			desc = strings.TrimSpace(desc + " (required)")
+		}
+		if opt.description != "" {
+			desc = strings.TrimPrefix(desc+"\n\n"+strings.TrimSpace(opt.description), "\n\n")
+		}
+		fmt.Fprintf(b, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(opt.names...),
+			opt.typ,
+			markdownCode(opt.def),
+			markdownCode(opt.env),
+			markdownCode(opt.choices...),
+			markdownCell(desc),
+		)
+	}
+	b.WriteString("\n")
+}
+
+// markdownCode returns strs as code spans of a table cell, eg: `-n`, `--name`
+func markdownCode(strs ...string) string {
+	codes := make([]string, 0, len(strs))
+	for _, s := range strs {
+		if s != "" {
+			codes = append(codes, "`"+markdownCell(s)+"`")
+		}
+	}
+	return strings.Join(codes, ", ")
+}
+
+// markdownCell escape pipes and line breaks of a table cell.
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", `\|`, "\n", "<br>").Replace(s)
+}
+
+// markdownAnchor returns the heading anchor of GitHub, eg: app remote add -> app-remote-add
+func markdownAnchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case r == ' ':
+			b.WriteRune('-')
+		case r == '-' || r == '_' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,56 @@
+package eflag
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func (opt *manOptions) Examples() []string {
+	return []string{"app -c prod list"}
+}
+
+func (opt *manOptions) DeployExamples() []string {
+	return []string{"app deploy web main.js", "app d -force web"}
+}
+
+func printMarkdown(t *testing.T, options ...EFlagOption) string {
+	setArgs(t)
+	var b strings.Builder
+	ef := NewEFlag(COMMAND_MODE_SUB_CMD, options...)
+	assert.Nil(t, ef.PrintMarkdown(&b, &manOptions{}))
+	return b.String()
+}
+
+func TestMarkdown(t *testing.T) {
+	assert := assert.New(t)
+
+	md := printMarkdown(t)
+	assert.True(strings.HasPrefix(md, "# app\n\ndeploy releases\n\nApp deploys files to clusters.\n.dot line\n\n```\napp [OPTIONS] COMMAND\n```\n\n## Options\n\n"))
+	assert.Contains(md, "| Option | Type | Default | Env | Choices | Description |\n| --- | --- | --- | --- | --- | --- |\n")
+	assert.Contains(md, "| `-c`, `-cluster` | string |  | `APP_CLUSTER` |  | cluster name<br><br>The cluster is one of prod-eu, staging. |\n")
+	assert.Contains(md, "| `-level` | string | `info` |  | `debug`, `info` | log level |\n")
+	assert.Contains(md, "| `-v` | bool |  |  |  | verbose output |\n\n## Examples\n\n```sh\napp -c prod list\n```\n\n")
+	assert.Contains(md, "## Commands\n\n| Command | Description |\n| --- | --- |\n| [`app deploy`](#app-deploy) | deploy files |\n| [`app list`](#app-list) | list releases |\n\n")
+
+	assert.Contains(md, "## app deploy\n\ndeploy files\n\nAliases: `d`\n\nDeploy files to the target.\n\n```\napp deploy [OPTIONS] TARGET FILES...\n```\n\n### Arguments\n\n")
+	assert.Contains(md, "| `TARGET` | string |  |  |  | (required) |\n| `FILES` | []string |  |  |  |  |\n")
+	assert.Contains(md, "| `-force` | bool |  |  |  | skip checks |\n\n### Examples\n\n```sh\napp deploy web main.js\napp d -force web\n```\n\n")
+	assert.True(strings.HasSuffix(md, "## app list\n\nlist releases\n\nList releases of the cluster.\n\n```\napp list [OPTIONS]\n```\n\n### Options\n\n"+
+		"| Option | Type | Default | Env | Choices | Description |\n| --- | --- | --- | --- | --- | --- |\n| `-format` | string |  |  |  | output format |\n"))
+
+	md = printMarkdown(t, WithGNUStyle())
+	assert.Contains(md, "| `-c`, `--cluster` | string |")
+
+	md = printMarkdown(t, WithProgramName("deployer"))
+	assert.True(strings.HasPrefix(md, "# deployer\n"))
+	assert.Contains(md, "## deployer deploy\n")
+}
+
+func TestMarkdownCell(t *testing.T) {
+	assert := assert.New(t)
+	assert.Equal(`a\|b<br>c`, markdownCell("a|b\nc"))
+	assert.Equal("`-n`, `--name`", markdownCode("-n", "", "--name"))
+	assert.Equal("app-remote-add", markdownAnchor("app remote add"))
+}
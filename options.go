@@ -1,12 +1,19 @@
 package eflag
 
+import (
+	"flag"
+	"strings"
+)
+
 var (
 	// default config
 	defaultConfig = Config{
-		TagName:      "flag",
-		TagNameShort: "flag_short",
-		ItemSep:      "@", // item1@item2@item3
-		MapSep:       "=", // key1=value1@key2=value2
+		TagName:       "flag",
+		TagNameShort:  "flag_short",
+		ItemSep:       "@", // item1@item2@item3
+		MapSep:        "=", // key1=value1@key2=value2
+		StrictValues:  true,
+		ErrorHandling: flag.ExitOnError,
 	}
 )
 
@@ -20,6 +27,26 @@ type Config struct {
 	ItemSep string
 	// map element separator
 	MapSep string
+	// environment variable prefix, eg: APP => APP_NAME
+	EnvPrefix string
+	// JSON config file path
+	ConfigFile string
+	// flag name of the config file, eg: config => -config=app.json
+	ConfigFlag string
+	// report invalid values instead of using the zero value
+	StrictValues bool
+	// behavior of command-line errors, eg: flag.ContinueOnError makes Parse return them
+	ErrorHandling flag.ErrorHandling
+	// GNU style options: --name, -n, -vxf
+	GNUStyle bool
+	// collect positional args from anywhere, eg: app file1 -v
+	Interspersed bool
+	// match sub-commands by unique prefix, eg: app st => app status
+	PrefixMatching bool
+	// print completion scripts by the completion command, eg: app completion bash
+	CompletionCommand bool
+	// program name of usage and documents, os.Args[0] if empty
+	ProgramName string
 }
 
 // EFlagOption
@@ -52,3 +79,76 @@ func WithMapSep(sep string) EFlagOption {
 		c.MapSep = sep
 	}
 }
+
+// Specify environment variable prefix.
+// Options without env tag are bound to PREFIX_FLAG_NAME.
+func WithEnvPrefix(prefix string) EFlagOption {
+	return func(c *Config) {
+		c.EnvPrefix = strings.TrimRight(prefix, "_")
+	}
+}
+
+// Specify JSON config file, applied before command-line options.
+func WithConfigFile(path string) EFlagOption {
+	return func(c *Config) {
+		c.ConfigFile = path
+	}
+}
+
+// Specify flag name used to pass the config file on command-line.
+func WithConfigFlag(name string) EFlagOption {
+	return func(c *Config) {
+		c.ConfigFlag = name
+	}
+}
+
+// Specify whether invalid values are reported, enabled by default.
+// When disabled, invalid numbers, booleans and durations become the zero value.
+func WithStrictValues(strict bool) EFlagOption {
+	return func(c *Config) {
+		c.StrictValues = strict
+	}
+}
+
+// Specify behavior of command-line errors, flag.ExitOnError by default.
+func WithErrorHandling(h flag.ErrorHandling) EFlagOption {
+	return func(c *Config) {
+		c.ErrorHandling = h
+	}
+}
+
+// Specify GNU style options: flag names are long options (--name=x, --name x),
+// flag_short names are short options (-n x, -nx) and bool ones cluster (-vxf).
+func WithGNUStyle() EFlagOption {
+	return func(c *Config) {
+		c.GNUStyle = true
+	}
+}
+
+// Specify that options may follow positional args, eg: app file1 -v
+func WithInterspersed() EFlagOption {
+	return func(c *Config) {
+		c.Interspersed = true
+	}
+}
+
+// Specify that sub-commands may be given by a unique prefix of their names or aliases.
+func WithPrefixMatching() EFlagOption {
+	return func(c *Config) {
+		c.PrefixMatching = true
+	}
+}
+
+// Specify that the completion command prints completion scripts, eg: app completion bash
+func WithCompletionCommand() EFlagOption {
+	return func(c *Config) {
+		c.CompletionCommand = true
+	}
+}
+
+// Specify the program name of usage and documents, eg: for go generate.
+func WithProgramName(name string) EFlagOption {
+	return func(c *Config) {
+		c.ProgramName = name
+	}
+}
@@ -1,58 +1,170 @@
 package eflag
 
 import (
+	"encoding"
 	"errors"
+	"flag"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
 	"time"
 )
 
+var (
+	flagValueType       = reflect.TypeOf((*flag.Value)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ValueError is returned when a string can not be converted to the option type.
+type ValueError struct {
+	Flag  string // flag name, empty when unknown
+	Input string // raw input, the item of slice and map values
+	Type  string // expected type, eg: int
+	Err   error
+}
+
+func (e *ValueError) Error() string {
+	msg := fmt.Sprintf("invalid value %q", e.Input)
+	if e.Flag != "" {
+		msg += " for -" + e.Flag
+	}
+	msg += ": expected " + e.Type
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *ValueError) Unwrap() error {
+	return e.Err
+}
+
+// valueParser convert string to value.
+// Unless strict, invalid numbers, booleans and durations silently become zero.
+type valueParser struct {
+	itemSep string
+	mapSep  string
+	strict  bool
+}
+
 // ParseValue convert string to the specified type based on typ.
 func ParseValue(typ reflect.Type, strval, itemSep, mapSep string) (val reflect.Value, err error) {
-	items := strings.Split(strval, itemSep)
-	switch typ.Kind() {
-	case reflect.Map:
-		val, err = ParseMap(typ, items, mapSep)
-	case reflect.Slice:
-		val, err = ParseSlice(typ, items)
-	default:
-		val, err = ParseAtomValue(typ.Kind(), strval)
-	}
-	return
+	p := &valueParser{itemSep: itemSep, mapSep: mapSep}
+	return p.parse(typ, strval)
 }
 
-// ParseMap convert items to map.
+// ParseMap convert items to map, each item split on the first mapSep.
+// Items without mapSep are skipped, like ParseValue does.
 func ParseMap(typ reflect.Type, items []string, mapSep string) (reflect.Value, error) {
+	p := &valueParser{mapSep: mapSep}
+	entries := make([][2]string, 0, len(items))
+	for _, item := range items {
+		kv, err := splitMapItems(item, "", mapSep, true)
+		if err != nil {
+			return reflect.Value{}, &ValueError{Input: item, Type: "key" + mapSep + "value", Err: err}
+		}
+		entries = append(entries, kv...)
+	}
+	return p.parseMap(typ, entries)
+}
+
+// ParseSlice convert items to slice.
+func ParseSlice(typ reflect.Type, items []string) (reflect.Value, error) {
+	p := &valueParser{}
+	return p.parseSlice(typ, items)
+}
+
+func (p *valueParser) parse(typ reflect.Type, strval string) (val reflect.Value, err error) {
+	if IsCustomType(typ) {
+		return p.parseAtom(typ, strval)
+	}
+	if typ.Kind() == reflect.Ptr {
+		return p.parsePtr(typ, strval)
+	}
+
+	if typ.Kind() != reflect.Map && typ.Kind() != reflect.Slice {
+		return p.parseAtom(typ, strval)
+	}
+	// empty string is an empty collection
+	if typ.Kind() == reflect.Map {
+		var entries [][2]string
+		if strval != "" {
+			if entries, err = splitMapItems(strval, p.itemSep, p.mapSep, !p.strict); err != nil {
+				return p.splitError(typ, strval, err)
+			}
+		}
+		return p.parseMap(typ, entries)
+	}
+
+	var items []string
+	if strval != "" {
+		if items, err = splitItems(strval, p.itemSep); err != nil {
+			return p.splitError(typ, strval, err)
+		}
+	}
+	return p.parseSlice(typ, items)
+}
+
+// splitError returns the error of splitting strval.
+// Unless strict, a value with unterminated quote is split as is.
+func (p *valueParser) splitError(typ reflect.Type, strval string, err error) (reflect.Value, error) {
+	if p.strict {
+		verr := &ValueError{Input: strval, Type: typ.String(), Err: err}
+		if err == ErrMissingMapSep {
+			verr.Type = "key" + p.mapSep + "value"
+		}
+		return reflect.Value{}, verr
+	}
+
+	items := strings.Split(strval, p.itemSep)
+	if typ.Kind() == reflect.Slice {
+		return p.parseSlice(typ, items)
+	}
+	entries := make([][2]string, 0, len(items))
+	for _, item := range items {
+		if kv := strings.SplitN(item, p.mapSep, 2); len(kv) == 2 {
+			entries = append(entries, [2]string{kv[0], kv[1]})
+		}
+	}
+	return p.parseMap(typ, entries)
+}
+
+// parsePtr convert string to a newly allocated pointer, eg: *int
+func (p *valueParser) parsePtr(typ reflect.Type, strval string) (reflect.Value, error) {
+	val, err := p.parse(typ.Elem(), strval)
+	if err != nil {
+		return val, err
+	}
+	ptr := reflect.New(typ.Elem())
+	ptr.Elem().Set(val)
+	return ptr, nil
+}
+
+func (p *valueParser) parseMap(typ reflect.Type, entries [][2]string) (reflect.Value, error) {
 	var val reflect.Value
 	rmap := reflect.MakeMap(reflect.MapOf(typ.Key(), typ.Elem()))
 
-	kkind := typ.Key().Kind()
-	vkind := typ.Elem().Kind()
-	for _, item := range items {
-		if elems := strings.Split(item, mapSep); len(elems) >= 2 {
-			kval, err := ParseAtomValue(kkind, elems[0])
-			if err != nil {
-				return val, err
-			}
-			vval, err := ParseAtomValue(vkind, elems[1])
-			if err != nil {
-				return val, err
-			}
-			rmap.SetMapIndex(kval, vval)
+	for _, kv := range entries {
+		kval, err := p.parseAtom(typ.Key(), kv[0])
+		if err != nil {
+			return val, err
 		}
+		vval, err := p.parseAtom(typ.Elem(), kv[1])
+		if err != nil {
+			return val, err
+		}
+		rmap.SetMapIndex(kval, vval)
 	}
 	return rmap, nil
 }
 
-// ParseSlice convert items to slice.
-func ParseSlice(typ reflect.Type, items []string) (reflect.Value, error) {
+func (p *valueParser) parseSlice(typ reflect.Type, items []string) (reflect.Value, error) {
 	var val reflect.Value
 	slice := reflect.MakeSlice(reflect.SliceOf(typ.Elem()), 0, len(items))
 
-	kind := typ.Elem().Kind()
 	for _, item := range items {
-		ival, err := ParseAtomValue(kind, item)
+		ival, err := p.parseAtom(typ.Elem(), item)
 		if err != nil {
 			return val, err
 		}
@@ -61,6 +173,95 @@ func ParseSlice(typ reflect.Type, items []string) (reflect.Value, error) {
 	return slice, nil
 }
 
+// parseAtom convert string to typ, which may be a custom type or a named basic type.
+func (p *valueParser) parseAtom(typ reflect.Type, strval string) (val reflect.Value, err error) {
+	switch {
+	case IsCustomType(typ):
+		val, err = ParseCustomValue(typ, strval)
+	case p.strict:
+		val, err = ParseAtomValueStrict(typ, strval)
+	case typ == durationType:
+		val = reflect.ValueOf(ParseDuration(strval, 0))
+	default:
+		val, err = ParseAtomValue(typ.Kind(), strval)
+	}
+	if err != nil {
+		if _, ok := err.(*ValueError); !ok {
+			err = &ValueError{Input: strval, Type: typ.String(), Err: err}
+		}
+		return val, err
+	}
+	return val.Convert(typ), nil
+}
+
+// IsCustomType report whether typ, or pointer to typ, implements flag.Value
+// or encoding.TextUnmarshaler.
+func IsCustomType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr && typ.Elem().Kind() != reflect.Ptr && IsCustomType(typ.Elem()) {
+		return true
+	}
+	ptr := reflect.PtrTo(typ)
+	return ptr.Implements(flagValueType) || ptr.Implements(textUnmarshalerType)
+}
+
+// ParseCustomValue convert string to typ through its flag.Value Set method,
+// or its encoding.TextUnmarshaler UnmarshalText method.
+// A pointer type is allocated, eg: *Level
+func ParseCustomValue(typ reflect.Type, strval string) (reflect.Value, error) {
+	if typ.Kind() == reflect.Ptr {
+		ptr := reflect.New(typ.Elem())
+		if err := setCustomValue(ptr, strval); err != nil {
+			return reflect.Value{}, err
+		}
+		return ptr, nil
+	}
+
+	ptr := reflect.New(typ)
+	if err := setCustomValue(ptr, strval); err != nil {
+		return reflect.Value{}, err
+	}
+	return ptr.Elem(), nil
+}
+
+func setCustomValue(ptr reflect.Value, strval string) error {
+	switch v := ptr.Interface().(type) {
+	case flag.Value:
+		return v.Set(strval)
+	case encoding.TextUnmarshaler:
+		return v.UnmarshalText([]byte(strval))
+	}
+	return errors.New("Unsupported custom type")
+}
+
+// ParseAtomValueStrict convert string to typ like ParseAtomValue,
+// but returns an error instead of the zero value when the conversion fails.
+func ParseAtomValueStrict(typ reflect.Type, strval string) (val reflect.Value, err error) {
+	var v interface{}
+	switch kind := typ.Kind(); {
+	case typ == durationType:
+		v, err = time.ParseDuration(strval)
+	case kind == reflect.Bool:
+		v, err = strconv.ParseBool(strval)
+	case kind == reflect.String:
+		v = strval
+	case isReflectType(typ, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64):
+		v, err = strconv.ParseInt(strval, 10, typ.Bits())
+	case isReflectType(typ, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64):
+		v, err = strconv.ParseUint(strval, 10, typ.Bits())
+	case isReflectType(typ, reflect.Float32, reflect.Float64):
+		v, err = strconv.ParseFloat(strval, typ.Bits())
+	default:
+		return val, errors.New("Unsupported kind type")
+	}
+	if numErr, ok := err.(*strconv.NumError); ok {
+		err = numErr.Err
+	}
+	if err != nil {
+		return val, err
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
+
 // ParseAtomValue convert string to the specified type based on kind.
 func ParseAtomValue(kind reflect.Kind, strval string) (val reflect.Value, err error) {
 	var v interface{}
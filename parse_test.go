@@ -0,0 +1,249 @@
+package eflag
+
+import (
+	"errors"
+	"flag"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Level implements flag.Value
+type Level int
+
+var levelNames = []string{"debug", "info", "warn"}
+
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+func (l *Level) Set(s string) error {
+	for i, name := range levelNames {
+		if name == s {
+			*l = Level(i)
+			return nil
+		}
+	}
+	return errors.New("unknown level " + s)
+}
+
+// Region implements encoding.TextUnmarshaler
+type Region struct {
+	Code string
+}
+
+func (r *Region) UnmarshalText(text []byte) error {
+	r.Code = strings.ToUpper(string(text))
+	return nil
+}
+
+func (r Region) MarshalText() ([]byte, error) {
+	return []byte(strings.ToLower(r.Code)), nil
+}
+
+type customOptions struct {
+	Level    Level            `flag:"level" default:"info" usage:"log level"`
+	LevelPtr *Level           `flag:"level-ptr" usage:"log level"`
+	Region   Region           `flag:"region" default:"cn" usage:"region code"`
+	IP       net.IP           `flag:"ip" usage:"listen ip"`
+	Levels   []Level          `flag:"levels" usage:"log levels"`
+	Regions  map[Region]Level `flag:"regions" usage:"region levels"`
+	Since    time.Time        `flag:"since" usage:"start time"`
+	Timeouts []time.Duration  `flag:"timeouts" usage:"timeouts"`
+}
+
+func TestCustomType(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-level=warn", "-level-ptr=debug", "-ip=127.0.0.1", "-levels=debug@warn",
+		"-regions=cn=info@us=warn", "-since=2020-01-02T03:04:05Z", "-timeouts=1s@10ms")
+	opt := &customOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION)
+	assert.Nil(ef.Parse(opt))
+
+	assert.Equal(Level(2), opt.Level)
+	if assert.NotNil(opt.LevelPtr) {
+		assert.Equal(Level(0), *opt.LevelPtr)
+	}
+	assert.Equal(Region{"CN"}, opt.Region)
+	assert.Equal(net.ParseIP("127.0.0.1"), opt.IP)
+	assert.Equal([]Level{0, 2}, opt.Levels)
+	assert.Equal(map[Region]Level{{"CN"}: 1, {"US"}: 2}, opt.Regions)
+	assert.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), opt.Since)
+	assert.Equal([]time.Duration{time.Second, 10 * time.Millisecond}, opt.Timeouts)
+
+	setArgs(t)
+	path := writeConfig(t, `{"level": "error", "region": "us"}`)
+	err := NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path)).Parse(&customOptions{})
+	assert.EqualError(err, `config file `+path+`: key "level": invalid value "error" for -level: expected eflag.Level: unknown level error`)
+}
+
+func TestFormatValue(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("warn", formatValue(reflect.ValueOf(Level(2)), "@", "="))
+	assert.Equal("cn=info", formatValue(reflect.ValueOf(map[Region]Level{{"CN"}: 1}), "@", "="))
+	assert.Equal("1s@10ms", formatValue(reflect.ValueOf([]time.Duration{time.Second, 10 * time.Millisecond}), "@", "="))
+}
+
+type pointerOptions struct {
+	Age     *int           `flag:"age" min:"1" usage:"user age"`
+	Name    *string        `flag:"name" usage:"user name"`
+	Man     *bool          `flag:"man" usage:"user sex"`
+	Sleep   *time.Duration `flag:"sleep" usage:"sleep duration"`
+	Salary  *float64       `flag:"salary" default:"1200" usage:"user salary"`
+	City    *string        `flag:"city" required:"true" usage:"user city"`
+	Country *string        `flag:"country" usage:"user country"`
+	Zip     *int           `flag:"zip" usage:"zip code"`
+}
+
+func (opt *pointerOptions) CountryDefault() string {
+	return "china"
+}
+
+func TestPointer(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-name=", "-man", "-city=beijing")
+	setEnv(t, "TEST_SLEEP", "1s")
+	path := writeConfig(t, `{"zip": 0}`)
+	opt := &pointerOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION, WithEnvPrefix("TEST"), WithConfigFile(path))
+	assert.Nil(ef.Parse(opt))
+
+	assert.Nil(opt.Age)
+	if assert.NotNil(opt.Name) {
+		assert.Equal("", *opt.Name)
+	}
+	if assert.NotNil(opt.Man) {
+		assert.True(*opt.Man)
+	}
+	if assert.NotNil(opt.Sleep) {
+		assert.Equal(time.Second, *opt.Sleep)
+	}
+	if assert.NotNil(opt.Salary) {
+		assert.Equal(1200.0, *opt.Salary)
+	}
+	if assert.NotNil(opt.Country) {
+		assert.Equal("china", *opt.Country)
+	}
+	if assert.NotNil(opt.Zip) {
+		assert.Equal(0, *opt.Zip)
+	}
+
+	setArgs(t, "-age=0")
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&pointerOptions{})
+	assert.EqualError(err, "missing required options: -city")
+
+	setArgs(t, "-age=0", "-city=")
+	err = NewEFlag(COMMAND_MODE_OPTION).Parse(&pointerOptions{})
+	assert.EqualError(err, `invalid value "0" for -age: must be >= 1`)
+}
+
+type strictOptions struct {
+	Age     int            `flag:"age" usage:"user age"`
+	Small   int8           `flag:"small" usage:"small number"`
+	Sleep   time.Duration  `flag:"sleep" usage:"sleep duration"`
+	Man     bool           `flag:"man" usage:"user sex"`
+	Ports   []uint16       `flag:"port" usage:"ports"`
+	Headers map[string]int `flag:"header" usage:"request header"`
+}
+
+func TestStrictValues(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := []struct {
+		arg   string
+		err   *ValueError
+		cause error
+	}{
+		{"-age=abc", &ValueError{Flag: "age", Input: "abc", Type: "int"}, strconv.ErrSyntax},
+		{"-small=300", &ValueError{Flag: "small", Input: "300", Type: "int8"}, strconv.ErrRange},
+		{"-sleep=10parsecs", &ValueError{Flag: "sleep", Input: "10parsecs", Type: "time.Duration"}, nil},
+		{"-man=yes", &ValueError{Flag: "man", Input: "yes", Type: "bool"}, strconv.ErrSyntax},
+		{"-port=80@http", &ValueError{Flag: "port", Input: "http", Type: "uint16"}, strconv.ErrSyntax},
+		{"-header=a=1@b", &ValueError{Flag: "header", Input: "a=1@b", Type: "key=value"}, ErrMissingMapSep},
+		{"-header=a=x", &ValueError{Flag: "header", Input: "x", Type: "int"}, strconv.ErrSyntax},
+	}
+	for _, c := range cases {
+		setArgs(t, c.arg)
+		err := NewEFlag(COMMAND_MODE_OPTION, WithErrorHandling(flag.ContinueOnError)).Parse(&strictOptions{})
+
+		var verr *ValueError
+		if assert.True(errors.As(err, &verr), c.arg) {
+			assert.Equal(c.err.Flag, verr.Flag, c.arg)
+			assert.Equal(c.err.Input, verr.Input, c.arg)
+			assert.Equal(c.err.Type, verr.Type, c.arg)
+			if c.cause != nil {
+				assert.True(errors.Is(err, c.cause), c.arg)
+			}
+		}
+	}
+
+	setArgs(t)
+	setEnv(t, "TEST_AGE", "abc")
+	err := NewEFlag(COMMAND_MODE_OPTION, WithEnvPrefix("TEST")).Parse(&strictOptions{})
+	assert.EqualError(err, `env TEST_AGE: invalid value "abc" for -age: expected int: invalid syntax`)
+}
+
+func TestLenientValues(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-age=abc", "-sleep=10parsecs", "-port=80@http")
+	opt := &strictOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION, WithStrictValues(false)).Parse(opt))
+	assert.Equal(0, opt.Age)
+	assert.Equal(time.Duration(0), opt.Sleep)
+	assert.Equal([]uint16{80, 0}, opt.Ports)
+}
+
+type repeatedOptions struct {
+	Addresses []string          `flag:"addr" default:"beijing@linzhou" usage:"home address"`
+	Headers   map[string]string `flag:"header" usage:"request header"`
+	Ports     []int             `flag:"port" usage:"ports"`
+	Name      string            `flag:"name" usage:"user name"`
+}
+
+func (opt *repeatedOptions) HeadersDefault() map[string]string {
+	return map[string]string{"lang": "golang"}
+}
+
+func TestRepeatedFlags(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-addr", "shanghai", "-addr", "hangzhou@suzhou", "-header=a=1", "-header", "b=2@a=3",
+		"-name=a", "-name=b")
+	opt := &repeatedOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION)
+	assert.Nil(ef.Parse(opt))
+	assert.Equal([]string{"shanghai", "hangzhou", "suzhou"}, opt.Addresses)
+	assert.Equal(map[string]string{"a": "3", "b": "2"}, opt.Headers)
+	assert.Equal("b", opt.Name)
+	assert.Nil(opt.Ports)
+
+	// an empty value clears defaults and earlier flags
+	setArgs(t, "-addr=", "-header=a=1", "-header=", "-header=b=2", "-port=")
+	opt = &repeatedOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(opt))
+	assert.Equal([]string{}, opt.Addresses)
+	assert.Equal(map[string]string{"b": "2"}, opt.Headers)
+	assert.Equal([]int{}, opt.Ports)
+}
+
+func TestParseMap(t *testing.T) {
+	assert := assert.New(t)
+
+	typ := reflect.TypeOf(map[string]int{})
+	rv, err := ParseMap(typ, []string{"a=1", "b", "c=2"}, "=")
+	assert.Nil(err)
+	assert.Equal(map[string]int{"a": 1, "c": 2}, rv.Interface())
+
+	rv, err = ParseValue(typ, "a=1@b@c=2", "@", "=")
+	assert.Nil(err)
+	assert.Equal(map[string]int{"a": 1, "c": 2}, rv.Interface())
+}
@@ -0,0 +1,121 @@
+package eflag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	REQUIRED_TAG_KEY    = "required"
+	REQUIRED_IF_TAG_KEY = "required_if"
+)
+
+// RequiredError is returned by Parse when required options are missing.
+type RequiredError struct {
+	// Missing flag names
+	Flags []string
+	// Missing positional arg names, eg: SRC
+	Args []string
+}
+
+func (e *RequiredError) Error() string {
+	var msgs []string
+	if len(e.Flags) > 0 {
+		names := make([]string, 0, len(e.Flags))
+		for _, name := range e.Flags {
+			names = append(names, "-"+name)
+		}
+		msgs = append(msgs, "missing required options: "+strings.Join(names, ", "))
+	}
+	if len(e.Args) > 0 {
+		msgs = append(msgs, "missing required args: "+strings.Join(e.Args, " "))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// requiredCond is a condition of required_if, eg: mode=remote
+type requiredCond struct {
+	name  string
+	value string
+}
+
+// parseRequired parse required and required_if tags of field.
+// Format of required_if: name1=value1,name2=value2, required when all conditions hold.
+func parseRequired(field reflect.StructField, f *flagField) error {
+	if tag, ok := field.Tag.Lookup(REQUIRED_TAG_KEY); ok {
+		f.required = ParseBool(tag, false)
+	}
+
+	tag := field.Tag.Get(REQUIRED_IF_TAG_KEY)
+	if tag == "" {
+		return nil
+	}
+	for _, cond := range strings.Split(tag, ",") {
+		parts := strings.SplitN(cond, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return fmt.Errorf("invalid required_if tag %q of %s", tag, f)
+		}
+		f.requiredIf = append(f.requiredIf, requiredCond{
+			name:  strings.TrimSpace(parts[0]),
+			value: strings.TrimSpace(parts[1]),
+		})
+	}
+	return nil
+}
+
+func formatRequiredConds(conds []requiredCond) string {
+	strs := make([]string, 0, len(conds))
+	for _, cond := range conds {
+		strs = append(strs, cond.name+"="+cond.value)
+	}
+	return strings.Join(strs, ",")
+}
+
+// checkRequiredConds returns an error if required_if refers to an unknown option.
+func (e *EFlag) checkRequiredConds() error {
+	for _, f := range e.fields {
+		for _, cond := range f.requiredIf {
+			if e.lookupField(cond.name) == nil {
+				return fmt.Errorf("required_if of %s refers to unknown option -%s", f, cond.name)
+			}
+		}
+	}
+	return nil
+}
+
+// isRequired report whether f must be provided, checking required_if against other options.
+func (e *EFlag) isRequired(f *flagField) bool {
+	if f.required {
+		return true
+	}
+	if len(f.requiredIf) == 0 {
+		return false
+	}
+	for _, cond := range f.requiredIf {
+		if e.lookupField(cond.name).value.String() != cond.value {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRequired returns a RequiredError listing every required option and
+// positional arg not set from any source, an explicit zero value is set.
+func (e *EFlag) checkRequired() error {
+	var missing, missingArgs []string
+	for _, f := range e.fields {
+		if e.isRequired(f) && e.isAllowed(f) && f.source.Kind == SOURCE_NONE {
+			missing = append(missing, f.name)
+		}
+	}
+	for _, af := range e.positionals {
+		if af.required && af.source.Kind == SOURCE_NONE {
+			missingArgs = append(missingArgs, af.name)
+		}
+	}
+	if len(missing) > 0 || len(missingArgs) > 0 {
+		return &RequiredError{Flags: missing, Args: missingArgs}
+	}
+	return nil
+}
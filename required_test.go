@@ -0,0 +1,71 @@
+package eflag
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type requiredOptions struct {
+	Name  string   `flag:"name" required:"true" usage:"user name"`
+	Mode  string   `flag:"mode" default:"local" usage:"run mode"`
+	Host  string   `flag:"host" required_if:"mode=remote" usage:"remote host"`
+	Addrs []string `flag:"addr" required:"true" usage:"home address"`
+	Age   int      `flag:"age" required:"false" usage:"user age"`
+}
+
+func TestRequired(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-mode=remote")
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&requiredOptions{})
+
+	var rerr *RequiredError
+	if assert.True(errors.As(err, &rerr)) {
+		assert.Equal([]string{"name", "host", "addr"}, rerr.Flags)
+		assert.Equal("missing required options: -name, -host, -addr", err.Error())
+	}
+
+	setArgs(t, "-name=lisi", "-addr=beijing")
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).Parse(&requiredOptions{}))
+}
+
+func TestRequiredZeroValue(t *testing.T) {
+	assert := assert.New(t)
+
+	opt := &struct {
+		N int    `flag:"n" required:"true"`
+		B bool   `flag:"b" required:"true"`
+		S string `arg:"0" required:"true"`
+	}{}
+	_, err := parseWith(t, COMMAND_MODE_OPTION, opt, []string{"-n=0", "-b=false", ""})
+	assert.Nil(err)
+
+	_, err = parseWith(t, COMMAND_MODE_OPTION, opt, nil)
+	assert.EqualError(err, "missing required options: -n, -b; missing required args: S")
+}
+
+func TestRequiredUsage(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-name=lisi", "-addr=beijing")
+	ef := NewEFlag(COMMAND_MODE_OPTION)
+	assert.Nil(ef.Parse(&requiredOptions{}))
+
+	ef.Usage()
+	usage := ef.errOutput.String()
+	assert.Contains(usage, "user name (required)")
+	assert.Contains(usage, "remote host (required if mode=remote)")
+	assert.NotContains(usage, "user age (required)")
+}
+
+func TestRequiredUnknownCond(t *testing.T) {
+	setArgs(t)
+
+	opt := &struct {
+		Host string `flag:"host" required_if:"mode=remote"`
+	}{}
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(opt)
+	assert.EqualError(t, err, "required_if of Host refers to unknown option -mode")
+}
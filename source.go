@@ -0,0 +1,53 @@
+package eflag
+
+type SourceKind uint
+
+const (
+	SOURCE_NONE           SourceKind = iota // Not set, the zero value of field
+	SOURCE_DEFAULT_TAG                      // default struct tag
+	SOURCE_DEFAULT_METHOD                   // <Field>Default method
+	SOURCE_CONFIG_FILE                      // JSON config file
+	SOURCE_ENV                              // Environment variable
+	SOURCE_COMMAND_LINE                     // Command-line option
+)
+
+// Source describes where the final value of an option came from.
+// Sources are applied in the order of SourceKind, each overriding the previous.
+type Source struct {
+	Kind SourceKind
+	// Name is the method name, config file path, environment variable
+	// or command-line flag the value came from.
+	Name string
+}
+
+func (s Source) String() string {
+	switch s.Kind {
+	case SOURCE_DEFAULT_TAG:
+		return "default"
+	case SOURCE_DEFAULT_METHOD:
+		return s.Name + "()"
+	case SOURCE_CONFIG_FILE:
+		return "config file " + s.Name
+	case SOURCE_ENV:
+		return "$" + s.Name
+	case SOURCE_COMMAND_LINE:
+		return s.Name
+	}
+	return "zero value"
+}
+
+// Source returns where the final value of field came from.
+// field is the struct field name, the flag name or the positional arg name.
+func (e *EFlag) Source(field string) Source {
+	for _, f := range e.fields {
+		if f.fieldName == field || f.name == field {
+			return f.source
+		}
+	}
+	for _, af := range e.positionals {
+		if af.fieldName == field || af.name == field {
+			return af.source
+		}
+	}
+	return Source{}
+}
@@ -0,0 +1,141 @@
+package eflag
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrUnterminatedQuote = errors.New("unterminated quote")
+	ErrMissingMapSep     = errors.New("missing map separator")
+)
+
+// splitter split slice and map values into items.
+//
+// An item may be quoted with " or ', and a backslash escapes a quote, a
+// backslash or a separator, eg: "a@b"@c\@d => [a@b c@d]
+// Map entries are split on the first separator only, eg: k=v=1 => k: v=1
+type splitter struct {
+	s   string
+	pos int
+}
+
+// splitItems split s on itemSep.
+func splitItems(s, itemSep string) ([]string, error) {
+	sp := &splitter{s: s}
+	var items []string
+	for {
+		item, stop, err := sp.readPart(itemSep)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if stop == "" {
+			return items, nil
+		}
+	}
+}
+
+// splitMapItems split s on itemSep, then each item on the first mapSep.
+// Items without mapSep are an error, or skipped when skipInvalid.
+func splitMapItems(s, itemSep, mapSep string, skipInvalid bool) ([][2]string, error) {
+	sp := &splitter{s: s}
+	var entries [][2]string
+	for {
+		key, stop, err := sp.readPart(mapSep, itemSep)
+		if err != nil {
+			return nil, err
+		}
+		if stop != mapSep {
+			if !skipInvalid {
+				return nil, ErrMissingMapSep
+			}
+			if stop == "" {
+				return entries, nil
+			}
+			continue
+		}
+		value, stop, err := sp.readPart(itemSep)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, [2]string{key, value})
+		if stop == "" {
+			return entries, nil
+		}
+	}
+}
+
+// readPart read an unquoted part up to the first of seps, consuming it.
+// stop is the separator found, empty at the end of s.
+func (sp *splitter) readPart(seps ...string) (part, stop string, err error) {
+	var b strings.Builder
+	if sp.pos < len(sp.s) && (sp.s[sp.pos] == '"' || sp.s[sp.pos] == '\'') {
+		if err := sp.readQuoted(&b); err != nil {
+			return "", "", err
+		}
+	}
+
+	for sp.pos < len(sp.s) {
+		if sep := sp.sepAt(sp.pos, seps); sep != "" {
+			sp.pos += len(sep)
+			return b.String(), sep, nil
+		}
+		ch := sp.s[sp.pos]
+		if ch == '\\' && sp.pos+1 < len(sp.s) {
+			if next := sp.pos + 1; isQuoteOrEscape(sp.s[next]) || sp.sepAt(next, seps) != "" {
+				sp.pos = next
+				ch = sp.s[sp.pos]
+			}
+		}
+		b.WriteByte(ch)
+		sp.pos++
+	}
+	return b.String(), "", nil
+}
+
+// readQuoted read a quoted string, without quotes, to b.
+func (sp *splitter) readQuoted(b *strings.Builder) error {
+	quote := sp.s[sp.pos]
+	for sp.pos++; sp.pos < len(sp.s); sp.pos++ {
+		ch := sp.s[sp.pos]
+		if ch == '\\' && sp.pos+1 < len(sp.s) {
+			sp.pos++
+			ch = sp.s[sp.pos]
+		} else if ch == quote {
+			sp.pos++
+			return nil
+		}
+		b.WriteByte(ch)
+	}
+	return ErrUnterminatedQuote
+}
+
+// sepAt returns the separator of seps at index i of s, if any.
+func (sp *splitter) sepAt(i int, seps []string) string {
+	for _, sep := range seps {
+		if sep != "" && strings.HasPrefix(sp.s[i:], sep) {
+			return sep
+		}
+	}
+	return ""
+}
+
+func isQuoteOrEscape(ch byte) bool {
+	return ch == '"' || ch == '\'' || ch == '\\'
+}
+
+// quoteItem quote s if it can not be split back as is, the reverse of splitItems.
+func quoteItem(s string, seps ...string) string {
+	needQuote := s == "" || isQuoteOrEscape(s[0]) || strings.Contains(s, `\`)
+	for _, sep := range seps {
+		if needQuote {
+			break
+		}
+		needQuote = sep != "" && strings.Contains(s, sep)
+	}
+	if !needQuote {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
@@ -0,0 +1,78 @@
+package eflag
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitItems(t *testing.T) {
+	assert := assert.New(t)
+
+	cases := map[string][]string{
+		`a@b@c`:                      {"a", "b", "c"},
+		`"lisi@example.com"@b`:       {"lisi@example.com", "b"},
+		`'a"b'@"c'd"`:                {`a"b`, `c'd`},
+		`a\@b@c`:                     {"a@b", "c"},
+		`C:\dir@D:\\`:                {`C:\dir`, `D:\`},
+		`@""@`:                       {"", "", ""},
+		`"a\"b"@x"y"`:                {`a"b`, `x"y"`},
+		`it's@O'Brien`:               {"it's", "O'Brien"},
+		`aGVsbG8=@"http://x/?a=1@2"`: {"aGVsbG8=", "http://x/?a=1@2"},
+	}
+	for s, items := range cases {
+		got, err := splitItems(s, "@")
+		assert.Nil(err, s)
+		assert.Equal(items, got, s)
+	}
+
+	_, err := splitItems(`"abc@d`, "@")
+	assert.Equal(ErrUnterminatedQuote, err)
+}
+
+func TestSplitMapItems(t *testing.T) {
+	assert := assert.New(t)
+
+	got, err := splitMapItems(`url=http://x/?a=1&b=2@"k=1"="v@1"@k\=2=v`, "@", "=", false)
+	assert.Nil(err)
+	assert.Equal([][2]string{{"url", "http://x/?a=1&b=2"}, {"k=1", "v@1"}, {"k=2", "v"}}, got)
+
+	_, err = splitMapItems(`a=1@b`, "@", "=", false)
+	assert.Equal(ErrMissingMapSep, err)
+
+	got, err = splitMapItems(`a=1@b@c=3`, "@", "=", true)
+	assert.Nil(err)
+	assert.Equal([][2]string{{"a", "1"}, {"c", "3"}}, got)
+}
+
+func TestQuoteItem(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, s := range []string{"a", "", "a@b", `"a`, `C:\dir`, `a"b@c`, "k=v"} {
+		items, err := splitItems(quoteItem(s, "@", "="), "@")
+		assert.Nil(err, s)
+		assert.Equal([]string{s}, items, s)
+	}
+	assert.Equal("abc", quoteItem("abc", "@"))
+	assert.Equal(`"a@b"`, quoteItem("a@b", "@"))
+}
+
+type sepOptions struct {
+	Emails  []string          `flag:"email" usage:"emails"`
+	Hosts   []string          `flag:"host" sep:"," usage:"hosts"`
+	Queries map[string]string `flag:"query" sep:"&" map_sep:":" usage:"queries"`
+}
+
+func TestSepTag(t *testing.T) {
+	assert := assert.New(t)
+
+	path := writeConfig(t, `{"email": ["a@example.com", "b"]}`)
+	setArgs(t, "-host=a,b,c@d", "-query=url:http://x/?a=1&b:2")
+	opt := &sepOptions{}
+	ef := NewEFlag(COMMAND_MODE_OPTION, WithConfigFile(path))
+	assert.Nil(ef.Parse(opt))
+	assert.Equal([]string{"a@example.com", "b"}, opt.Emails)
+	assert.Equal([]string{"a", "b", "c@d"}, opt.Hosts)
+	assert.Equal(map[string]string{"url": "http://x/?a=1", "b": "2"}, opt.Queries)
+	assert.Equal(`"a@example.com"@b`, ef.lookupField("email").value.String())
+}
@@ -0,0 +1,113 @@
+package eflag
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// suggest returns the candidate closest to name by edit distance, empty if
+// none is close enough. Names of one character get no suggestion.
+func suggest(name string, candidates []string) string {
+	if len(name) < 2 {
+		return ""
+	}
+	best, bestDist := "", (len(name)+2)/3+1
+	for _, candidate := range candidates {
+		if dist := editDistance(name, candidate); dist < bestDist {
+			best, bestDist = candidate, dist
+		}
+	}
+	return best
+}
+
+// editDistance returns the optimal string alignment distance of a and b,
+// counting insertions, deletions, substitutions and transpositions.
+func editDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = minInt(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = minInt(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+func minInt(n int, others ...int) int {
+	for _, o := range others {
+		if o < n {
+			n = o
+		}
+	}
+	return n
+}
+
+// suggestFlag returns ", did you mean -name?" for an unknown flag name, or empty.
+func (e *EFlag) suggestFlag(name string) string {
+	var names []string
+	e.flagSet.VisitAll(func(fl *flag.Flag) {
+		if e.isFlagAllowed(fl.Name) {
+			names = append(names, fl.Name)
+		}
+	})
+	if s := suggest(name, names); s != "" {
+		return fmt.Sprintf(", did you mean %s?", e.flagDisplayName(s))
+	}
+	return ""
+}
+
+// suggestCommand returns ", did you mean 'show'?" for an unknown sub-command name, or empty.
+func (e *EFlag) suggestCommand(name string) string {
+	var names []string
+	for _, cmd := range e.commandList {
+		names = append(names, cmd.names()...)
+	}
+	if s := suggest(name, names); s != "" {
+		return fmt.Sprintf(", did you mean '%s'?", s)
+	}
+	return ""
+}
+
+// checkUnknownFlags returns an error with a suggestion if args has a flag
+// not defined, before the flag package reports it without one.
+func (e *EFlag) checkUnknownFlags(args []string) error {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			if !e.config.Interspersed {
+				return nil
+			}
+			continue
+		}
+
+		name, _, hasValue := splitOption(strings.TrimPrefix(arg[1:], "-"))
+		fl := e.flagSet.Lookup(name)
+		if fl == nil {
+			if name == "h" || name == "help" {
+				return nil // print usage when parsing
+			}
+			return e.failf("flag provided but not defined: -%s%s", name, e.suggestFlag(name))
+		}
+		if !hasValue && !isBoolValue(fl.Value) {
+			i++
+		}
+	}
+	return nil
+}
@@ -9,7 +9,13 @@ func ReflectVisitStructField(v interface{}, ignoreAnonymous bool, fn func(vType
 		return
 	}
 
-	rv := reflect.ValueOf(v)
+	reflectVisitStructValue(reflect.ValueOf(v), ignoreAnonymous, fn)
+}
+
+// reflectVisitStructValue is ReflectVisitStructField of a struct or struct pointer value,
+// which may be obtained from an unexported embedded field.
+func reflectVisitStructValue(rawRv reflect.Value, ignoreAnonymous bool, fn func(vType reflect.Value, field reflect.StructField, fieldValue reflect.Value) bool) {
+	rv := rawRv
 	if rv.Kind() == reflect.Ptr {
 		rv = rv.Elem()
 		if rv.Kind() != reflect.Struct {
@@ -19,7 +25,6 @@ func ReflectVisitStructField(v interface{}, ignoreAnonymous bool, fn func(vType
 		return
 	}
 
-	rawRv := reflect.ValueOf(v)
 	rt := rv.Type()
 	for i := 0; i < rt.NumField(); i++ {
 		field := rt.Field(i)
@@ -0,0 +1,334 @@
+package eflag
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	VALIDATE_MIN_TAG_KEY     = "min"
+	VALIDATE_MAX_TAG_KEY     = "max"
+	VALIDATE_MINLEN_TAG_KEY  = "minlen"
+	VALIDATE_MAXLEN_TAG_KEY  = "maxlen"
+	VALIDATE_PATTERN_TAG_KEY = "pattern"
+	VALIDATE_ONEOF_TAG_KEY   = "oneof"
+
+	ONEOF_SEP = "|" // debug|info|warn
+
+	VALIDATE_METHOD_NAME_KEY = "Validate"
+)
+
+// ValidationError is returned by Parse when an option violates a validation tag.
+type ValidationError struct {
+	Flag  string // flag name
+	Value string // offending value
+	Rule  string // tag key, eg: min
+	Arg   string // tag value, eg: 1
+}
+
+func (e *ValidationError) Error() string {
+	var msg string
+	switch e.Rule {
+	case VALIDATE_MIN_TAG_KEY:
+		msg = "must be >= " + e.Arg
+	case VALIDATE_MAX_TAG_KEY:
+		msg = "must be <= " + e.Arg
+	case VALIDATE_MINLEN_TAG_KEY:
+		msg = "length must be >= " + e.Arg
+	case VALIDATE_MAXLEN_TAG_KEY:
+		msg = "length must be <= " + e.Arg
+	case VALIDATE_PATTERN_TAG_KEY:
+		msg = "must match " + e.Arg
+	case VALIDATE_ONEOF_TAG_KEY:
+		msg = "must be one of " + e.Arg
+	default:
+		msg = "violates " + e.Rule + "=" + e.Arg
+	}
+	return fmt.Sprintf("invalid value %q for -%s: %s", e.Value, e.Flag, msg)
+}
+
+// ValidationErrors is returned by Parse with every violation found.
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	strs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		strs = append(strs, err.Error())
+	}
+	return strings.Join(strs, "; ")
+}
+
+// validateRule checks a value against one validation tag.
+type validateRule struct {
+	key   string
+	arg   string
+	each  bool // check elements of slice and map values, instead of the whole value
+	check func(rv reflect.Value) bool
+}
+
+// parseRules parse the validation tags of field.
+// Rules of pointer fields apply to the element, eg: *int
+func parseRules(field reflect.StructField, f *flagField) error {
+	typ := field.Type
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	for _, key := range []string{
+		VALIDATE_MIN_TAG_KEY, VALIDATE_MAX_TAG_KEY,
+		VALIDATE_MINLEN_TAG_KEY, VALIDATE_MAXLEN_TAG_KEY,
+		VALIDATE_PATTERN_TAG_KEY, VALIDATE_ONEOF_TAG_KEY,
+	} {
+		arg, ok := field.Tag.Lookup(key)
+		if !ok {
+			continue
+		}
+		rule, err := newValidateRule(typ, key, arg)
+		if err != nil {
+			return fmt.Errorf("invalid %s tag %q of %s: %v", key, arg, f, err)
+		}
+		f.rules = append(f.rules, rule)
+		if key == VALIDATE_ONEOF_TAG_KEY {
+			f.choices = strings.Split(arg, ONEOF_SEP)
+		}
+	}
+	return nil
+}
+
+func newValidateRule(typ reflect.Type, key, arg string) (*validateRule, error) {
+	rule := &validateRule{key: key, arg: arg}
+	elemTyp := typ
+	if typ.Kind() == reflect.Slice || typ.Kind() == reflect.Map {
+		elemTyp = typ.Elem()
+	}
+
+	switch key {
+	case VALIDATE_MIN_TAG_KEY, VALIDATE_MAX_TAG_KEY:
+		bound, err := parseBound(typ, arg)
+		if err != nil {
+			return nil, err
+		}
+		sign := -1 // min: value < bound is invalid
+		if key == VALIDATE_MAX_TAG_KEY {
+			sign = 1
+		}
+		rule.check = func(rv reflect.Value) bool {
+			return compareNumber(rv, bound) != sign
+		}
+	case VALIDATE_MINLEN_TAG_KEY, VALIDATE_MAXLEN_TAG_KEY:
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !isReflectType(typ, reflect.String, reflect.Slice, reflect.Map) {
+			return nil, fmt.Errorf("unsupported type %s", typ)
+		}
+		rule.check = func(rv reflect.Value) bool {
+			l := rv.Len()
+			if rv.Kind() == reflect.String {
+				l = utf8.RuneCountInString(rv.String())
+			}
+			if key == VALIDATE_MINLEN_TAG_KEY {
+				return l >= n
+			}
+			return l <= n
+		}
+	case VALIDATE_PATTERN_TAG_KEY:
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return nil, err
+		}
+		if elemTyp.Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported type %s", typ)
+		}
+		rule.each = elemTyp != typ
+		rule.check = func(rv reflect.Value) bool {
+			return re.MatchString(rv.String())
+		}
+	case VALIDATE_ONEOF_TAG_KEY:
+		choices := strings.Split(arg, ONEOF_SEP)
+		rule.each = elemTyp != typ
+		rule.check = func(rv reflect.Value) bool {
+			s := fmt.Sprint(rv.Interface())
+			for _, choice := range choices {
+				if s == choice {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return rule, nil
+}
+
+// parseBound parse the min/max tag value to the numeric type typ.
+func parseBound(typ reflect.Type, arg string) (reflect.Value, error) {
+	var v interface{}
+	var err error
+	switch {
+	case typ == durationType:
+		v, err = time.ParseDuration(arg)
+	case isReflectType(typ, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64):
+		v, err = strconv.ParseInt(arg, 10, 64)
+	case isReflectType(typ, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64):
+		v, err = strconv.ParseUint(arg, 10, 64)
+	case isReflectType(typ, reflect.Float32, reflect.Float64):
+		v, err = strconv.ParseFloat(arg, 64)
+	default:
+		return reflect.Value{}, fmt.Errorf("unsupported type %s", typ)
+	}
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
+
+// compareNumber returns -1, 0 or 1 as a is less than, equal to or greater than b.
+func compareNumber(a, b reflect.Value) int {
+	switch {
+	case isReflectType(a.Type(), reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64):
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+	case isReflectType(a.Type(), reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64):
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+	}
+	return compareOrdered(a.Float() < b.Float(), a.Float() > b.Float())
+}
+
+func compareOrdered(less, greater bool) int {
+	if less {
+		return -1
+	} else if greater {
+		return 1
+	}
+	return 0
+}
+
+// checkRules returns ValidationErrors with every rule violated by options
+// set from any source.
+func (e *EFlag) checkRules() error {
+	var errs ValidationErrors
+	for _, f := range e.fields {
+		rv := reflect.Indirect(f.val.rval)
+		if f.source.Kind == SOURCE_NONE || !rv.IsValid() {
+			continue
+		}
+		for _, rule := range f.rules {
+			for _, item := range ruleItems(rule, rv) {
+				if !rule.check(item) {
+					errs = append(errs, &ValidationError{
+						Flag:  f.name,
+						Value: formatValue(item, f.val.itemSep, f.val.mapSep),
+						Rule:  rule.key,
+						Arg:   rule.arg,
+					})
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// ruleItems returns the values rule checks, the elements of rv when rule.each.
+func ruleItems(rule *validateRule, rv reflect.Value) []reflect.Value {
+	if !rule.each {
+		return []reflect.Value{rv}
+	}
+
+	items := make([]reflect.Value, 0, rv.Len())
+	if rv.Kind() == reflect.Map {
+		iter := rv.MapRange()
+		for iter.Next() {
+			items = append(items, iter.Value())
+		}
+	} else {
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, rv.Index(i))
+		}
+	}
+	return items
+}
+
+// FieldError wraps the error returned by a <Field>Validate method,
+// or by the Validate method of a nested struct.
+type FieldError struct {
+	Field string // field path, eg: DB.Host
+	Flag  string // flag name, empty for nested struct
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	if e.Flag == "" {
+		return fmt.Sprintf("%s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("invalid -%s (%s): %v", e.Flag, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// structValidator is the Validate method of the options struct or a nested struct.
+type structValidator struct {
+	path   string // field path of nested struct, empty for the options struct
+	method reflect.Value
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// validateMethod returns method name of rv if it is declared, checking it is func() error.
+func validateMethod(rv reflect.Value, name string) (reflect.Value, error) {
+	rm := rv.MethodByName(name)
+	if !rm.IsValid() {
+		return rm, nil
+	}
+	if typ := rm.Type(); typ.NumIn() != 0 || typ.NumOut() != 1 || typ.Out(0) != errorType {
+		return reflect.Value{}, fmt.Errorf("method %s of %s must be func() error", name, rv.Type())
+	}
+	return rm, nil
+}
+
+// parseStructValidator register the Validate method of struct pointer rv.
+func (e *EFlag) parseStructValidator(rv reflect.Value, path string) error {
+	rm, err := validateMethod(rv, VALIDATE_METHOD_NAME_KEY)
+	if err == nil && rm.IsValid() {
+		e.validators = append(e.validators, &structValidator{path: path, method: rm})
+	}
+	return err
+}
+
+// callValidateMethod returns the error of func() error method rm.
+func callValidateMethod(rm reflect.Value) error {
+	if err, _ := rm.Call(nil)[0].Interface().(error); err != nil {
+		return err
+	}
+	return nil
+}
+
+// checkMethods call <Field>Validate methods, then Validate methods of nested
+// structs and the options struct, returning the first error.
+func (e *EFlag) checkMethods() error {
+	for _, f := range e.fields {
+		if !f.validate.IsValid() {
+			continue
+		}
+		if err := callValidateMethod(f.validate); err != nil {
+			return &FieldError{Field: f.fieldName, Flag: f.name, Err: err}
+		}
+	}
+	for _, v := range e.validators {
+		if err := callValidateMethod(v.method); err != nil {
+			if v.path == "" {
+				return err
+			}
+			return &FieldError{Field: v.path, Err: err}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package eflag
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type validateOptions struct {
+	Port    int           `flag:"port" default:"80" min:"1" max:"65535" usage:"listen port"`
+	Ratio   float64       `flag:"ratio" min:"0" max:"1" usage:"sample ratio"`
+	Timeout time.Duration `flag:"timeout" default:"1s" min:"10ms" max:"1m" usage:"timeout"`
+	Name    string        `flag:"name" minlen:"2" maxlen:"4" usage:"user name"`
+	Addrs   []string      `flag:"addr" maxlen:"2" pattern:"^[a-z]+$" usage:"home address"`
+	Level   string        `flag:"level" default:"info" oneof:"debug|info|warn" usage:"log level"`
+	Codes   []int         `flag:"code" oneof:"200|404" usage:"status codes"`
+}
+
+func TestValidate(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-port=8080", "-ratio=0.5", "-name=李四", "-addr=beijing@linzhou", "-level=warn", "-code=404")
+	ef := NewEFlag(COMMAND_MODE_OPTION)
+	assert.Nil(ef.Parse(&validateOptions{}))
+
+	ef.Usage()
+	assert.Contains(ef.errOutput.String(), "log level (one of debug|info|warn)")
+}
+
+func TestValidateError(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-port=0", "-timeout=2m", "-name=a", "-addr=a@B@c", "-level=error", "-code=200@500")
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(&validateOptions{})
+
+	var errs ValidationErrors
+	if !assert.True(errors.As(err, &errs)) {
+		return
+	}
+	assert.Equal(&ValidationError{Flag: "port", Value: "0", Rule: "min", Arg: "1"}, errs[0])
+	assert.Equal(`invalid value "0" for -port: must be >= 1`, errs[0].Error())
+
+	rules := []string{}
+	for _, e := range errs {
+		rules = append(rules, e.Flag+":"+e.Rule+":"+e.Value)
+	}
+	assert.Equal([]string{
+		"port:min:0",
+		"timeout:max:2m0s",
+		"name:minlen:a",
+		"addr:maxlen:a@B@c",
+		"addr:pattern:B",
+		"level:oneof:error",
+		"code:oneof:500",
+	}, rules)
+}
+
+func TestValidateInvalidTag(t *testing.T) {
+	setArgs(t)
+
+	opt := &struct {
+		Name string `flag:"name" min:"1"`
+	}{}
+	err := NewEFlag(COMMAND_MODE_OPTION).Parse(opt)
+	assert.EqualError(t, err, `invalid min tag "1" of Name: unsupported type string`)
+}
+
+type tlsOptions struct {
+	Cert string `flag:"cert" usage:"certificate file"`
+	Key  string `flag:"key" usage:"key file"`
+}
+
+func (opt *tlsOptions) Validate() error {
+	if opt.Cert != "" && opt.Key == "" {
+		return errors.New("--tls.cert needs --tls.key")
+	}
+	return nil
+}
+
+type methodOptions struct {
+	Port int        `flag:"port" usage:"listen port"`
+	Mode string     `flag:"mode" usage:"run mode"`
+	TLS  tlsOptions // -tls.cert
+	Run  bool       `flag:"run" command:""`
+
+	ran bool
+}
+
+func (opt *methodOptions) PortValidate() error {
+	if opt.Port%2 != 0 {
+		return errors.New("must be even")
+	}
+	return nil
+}
+
+func (opt *methodOptions) Validate() error {
+	if opt.Mode == "remote" && opt.TLS.Cert == "" {
+		return errors.New("remote mode needs --tls.cert")
+	}
+	return nil
+}
+
+func (opt *methodOptions) RunCommand() {
+	opt.ran = true
+}
+
+func TestValidateMethod(t *testing.T) {
+	assert := assert.New(t)
+
+	setArgs(t, "-port=1", "-run")
+	opt := &methodOptions{}
+	err := NewEFlag(COMMAND_MODE_OPTION).ParseAndRunCommand(opt)
+	assert.EqualError(err, "invalid -port (Port): must be even")
+	var ferr *FieldError
+	assert.True(errors.As(err, &ferr))
+	assert.False(opt.ran)
+
+	setArgs(t, "-tls.cert=a.pem")
+	err = NewEFlag(COMMAND_MODE_OPTION).Parse(&methodOptions{})
+	assert.EqualError(err, "TLS: --tls.cert needs --tls.key")
+
+	setArgs(t, "-mode=remote")
+	err = NewEFlag(COMMAND_MODE_OPTION).Parse(&methodOptions{})
+	assert.EqualError(err, "remote mode needs --tls.cert")
+
+	setArgs(t, "-port=2", "-run")
+	opt = &methodOptions{}
+	assert.Nil(NewEFlag(COMMAND_MODE_OPTION).ParseAndRunCommand(opt))
+	assert.True(opt.ran)
+}
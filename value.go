@@ -1,23 +1,41 @@
 package eflag
 
 import (
+	"encoding"
+	"fmt"
 	"reflect"
+	"sort"
+	"strings"
 	"time"
 )
 
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
 // Value implemented flag.Value interface.
 type Value struct {
 	val    string
 	rval   reflect.Value
 	config *Config
+	name   string // flag name, reported by ValueError
+
+	// separators of slice and map values, from config or sep and map_sep tags
+	itemSep string
+	mapSep  string
+	err     error // error of the last Set
+
+	repeated bool // set from command-line before
 }
 
 // NewValue is the constructor of Value.
 func NewValue(v string, rv reflect.Value, c *Config) *Value {
 	return &Value{
-		val:    v,
-		rval:   rv,
-		config: c,
+		val:     v,
+		rval:    rv,
+		config:  c,
+		itemSep: c.ItemSep,
+		mapSep:  c.MapSep,
 	}
 }
 
@@ -26,21 +44,132 @@ func (v *Value) String() string {
 	return v.val
 }
 
-// Set set new value.
+// Set set new value from command-line.
+// Repeated flags of slice and map fields accumulate: the first one replaces
+// the value from other sources, the later ones append to slices and merge
+// into maps. An empty value clears the collection, eg: -addr=
 func (v *Value) Set(sval string) error {
-	// first check time.Duration
-	if _, ok := v.rval.Interface().(time.Duration); ok {
-		v.rval.SetInt(int64(ParseDuration(sval, 0)))
-		return nil
+	if !v.isCollection() || sval == "" || !v.repeated {
+		v.repeated = true
+		return v.replace(sval)
 	}
 
-	if val, err := ParseValue(v.rval.Type(), sval, v.config.ItemSep, v.config.MapSep); err != nil {
+	val, err := v.parse(sval)
+	if err != nil {
 		return err
+	}
+	if v.rval.Kind() == reflect.Slice {
+		val = reflect.AppendSlice(v.rval, val)
+	} else {
+		iter := val.MapRange()
+		for iter.Next() {
+			v.rval.SetMapIndex(iter.Key(), iter.Value())
+		}
+		val = v.rval
+	}
+	v.setValue(val)
+	return nil
+}
+
+// replace set new value, discarding the current one.
+func (v *Value) replace(sval string) error {
+	val, err := v.parse(sval)
+	if err != nil {
+		return err
+	}
+	v.rval.Set(val)
+	v.val = sval
+	return nil
+}
+
+func (v *Value) parse(sval string) (reflect.Value, error) {
+	p := &valueParser{
+		itemSep: v.itemSep,
+		mapSep:  v.mapSep,
+		strict:  v.config.StrictValues,
+	}
+	val, err := p.parse(v.rval.Type(), sval)
+	if verr, ok := err.(*ValueError); ok && verr.Flag == "" {
+		verr.Flag = v.name
+	}
+	v.err = err
+	return val, err
+}
+
+// isCollection report whether repeated flags accumulate, eg: []string
+func (v *Value) isCollection() bool {
+	return isReflectType(v.rval.Type(), reflect.Slice, reflect.Map) && !IsCustomType(v.rval.Type())
+}
+
+// setValue set rv directly, keeping String in command-line form.
+// A value of the element type of a pointer field is allocated, eg: int for *int
+func (v *Value) setValue(rv reflect.Value) {
+	if typ := v.rval.Type(); typ.Kind() == reflect.Ptr && rv.Type() == typ.Elem() {
+		ptr := reflect.New(typ.Elem())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+	v.rval.Set(rv)
+	v.val = formatValue(v.rval, v.itemSep, v.mapSep)
+}
+
+// formatValue convert rv to command-line form, the reverse of ParseValue.
+// Items containing a separator are quoted.
+func formatValue(rv reflect.Value, itemSep, mapSep string) string {
+	if IsCustomType(rv.Type()) {
+		return formatAtom(rv)
+	}
+
+	switch rv.Kind() {
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return ""
+		}
+		return formatValue(rv.Elem(), itemSep, mapSep)
+	case reflect.Slice:
+		items := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, quoteItem(formatAtom(rv.Index(i)), itemSep))
+		}
+		return strings.Join(items, itemSep)
+	case reflect.Map:
+		items := make([]string, 0, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			items = append(items, quoteItem(formatAtom(iter.Key()), itemSep, mapSep)+mapSep+quoteItem(formatAtom(iter.Value()), itemSep))
+		}
+		sort.Strings(items)
+		return strings.Join(items, itemSep)
+	}
+	return formatAtom(rv)
+}
+
+// formatAtom convert scalar rv to string, through the String or MarshalText
+// method of custom types.
+func formatAtom(rv reflect.Value) string {
+	if rv.Type() == durationType {
+		return time.Duration(rv.Int()).String()
+	}
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
 	} else {
-		v.rval.Set(val)
-		v.val = sval
-		return nil
+		// pointer receiver methods need an addressable value
+		ptr := reflect.New(rv.Type())
+		ptr.Elem().Set(rv)
+		rv = ptr
+	}
+
+	switch v := rv.Interface().(type) {
+	case encoding.TextMarshaler:
+		if text, err := v.MarshalText(); err == nil {
+			return string(text)
+		}
+	case fmt.Stringer:
+		return v.String()
 	}
+	return fmt.Sprint(rv.Elem().Interface())
 }
 
 // BoolValue set flag as bool option.